@@ -0,0 +1,270 @@
+package mdplib
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// DiscountSensitivity estimates dV/dγ for every state with a central
+// difference, solving value iteration at Discount+delta and Discount-delta.
+func (m *MDP) DiscountSensitivity(delta float64) map[State]float64 {
+	upper, _ := m.solveAt(m.Discount + delta)
+	lower, _ := m.solveAt(m.Discount - delta)
+
+	sensitivity := make(map[State]float64)
+	for _, s := range m.States {
+		sensitivity[s] = (upper[s] - lower[s]) / (2 * delta)
+	}
+	return sensitivity
+}
+
+// PolicyComparisonAcrossDiscount solves the MDP at discounts g1 and g2 and
+// returns, in States order, the states whose greedy action differs between
+// them. Discount, ValueFunc and Policy are left untouched.
+func (m *MDP) PolicyComparisonAcrossDiscount(g1, g2 float64) (changed []State) {
+	_, p1 := m.solveAt(g1)
+	_, p2 := m.solveAt(g2)
+	for _, s := range m.States {
+		if p1[s] != p2[s] {
+			changed = append(changed, s)
+		}
+	}
+	return changed
+}
+
+// ValueErrorBound returns γ/(1-γ) times the Bellman residual of the last
+// value iteration sweep, a guaranteed bound on max_s |ValueFunc(s) - V*(s)|.
+// To reach accuracy ε, iterate until the bound drops below ε, i.e. until the
+// residual is under ε(1-γ)/γ. It is +Inf when ValueFunc doesn't come from
+// value iteration or γ >= 1.
+func (m *MDP) ValueErrorBound() float64 {
+	if m.last.solver != SolverValueIteration || m.Discount >= 1 {
+		return math.Inf(1)
+	}
+	return m.last.residual * m.Discount / (1 - m.Discount)
+}
+
+// EffectiveHorizon returns 1/(1-Discount), roughly how many steps ahead the
+// discount lets rewards matter (+Inf for γ >= 1).
+func (m *MDP) EffectiveHorizon() float64 {
+	if m.Discount >= 1 {
+		return math.Inf(1)
+	}
+	return 1 / (1 - m.Discount)
+}
+
+// CheckHorizon returns a warning error when MaxIterations is below the
+// effective horizon, so value iteration stops before rewards that far ahead
+// have propagated back. Converging to Tolerance typically needs several
+// horizons' worth of sweeps.
+func (m *MDP) CheckHorizon() error {
+	horizon := m.EffectiveHorizon()
+	if float64(m.MaxIterations) < horizon {
+		return fmt.Errorf("CheckHorizon: MaxIterations %d is below the effective horizon %.4g of discount %v", m.MaxIterations, horizon, m.Discount)
+	}
+	return nil
+}
+
+// solveAt runs value iteration from scratch at the given discount and returns
+// the resulting values and greedy policy, leaving Discount, ValueFunc and
+// Policy untouched.
+func (m *MDP) solveAt(discount float64) (map[State]float64, map[State]Action) {
+	origDiscount, origValues, origLast := m.Discount, m.ValueFunc, m.last
+	defer func() {
+		m.Discount, m.ValueFunc, m.last = origDiscount, origValues, origLast
+	}()
+
+	m.Discount = discount
+	m.ValueFunc = make(map[State]float64)
+	m.ValueIteration()
+	return m.ValueFunc, m.greedyPolicy(m.ValueFunc)
+}
+
+// ValueIterationWithRewardNoise propagates reward uncertainty into the values:
+// it solves the MDP samples times, each time drawing every transition's reward
+// from N(Reward, rewardStd[s][a]²), and returns the per-state mean and sample
+// standard deviation of the resulting values. Pairs missing from rewardStd
+// are noise-free. ValueFunc is left untouched.
+func (m *MDP) ValueIterationWithRewardNoise(rewardStd map[State]map[Action]float64, samples int, rng *rand.Rand) (mean, std map[State]float64) {
+	origValues, origLast := m.ValueFunc, m.last
+	defer func() { m.ValueFunc, m.last = origValues, origLast }()
+
+	mean = make(map[State]float64, len(m.States))
+	sumSq := make(map[State]float64, len(m.States))
+	for i := 0; i < samples; i++ {
+		noisy := m.transitionTable()
+		for _, s := range m.States {
+			for _, a := range m.actionsFor(s) {
+				if sd := rewardStd[s][a]; sd != 0 {
+					for k := range noisy[s][a] {
+						noisy[s][a][k].Reward += sd * rng.NormFloat64()
+					}
+				}
+			}
+		}
+
+		m.ValueFunc = make(map[State]float64)
+		m.ValueIterationModel(noisy)
+		// Welford's update keeps the variance exact when samples agree.
+		for _, s := range m.States {
+			d := m.ValueFunc[s] - mean[s]
+			mean[s] += d / float64(i+1)
+			sumSq[s] += d * (m.ValueFunc[s] - mean[s])
+		}
+	}
+
+	std = make(map[State]float64, len(m.States))
+	for _, s := range m.States {
+		if samples > 1 {
+			std[s] = math.Sqrt(sumSq[s] / float64(samples-1))
+		} else {
+			std[s] = 0
+		}
+	}
+	return mean, std
+}
+
+// discountSearchTolerance is the precision of OptimalPolicyDiscountRange, and
+// maxSearchDiscount its upper limit, since value iteration converges too
+// slowly to be useful as γ approaches 1.
+const (
+	discountSearchTolerance = 1e-4
+	maxSearchDiscount       = 0.999
+)
+
+// OptimalPolicyDiscountRange bisects for the interval [low, high] around the
+// current Discount in which the optimal greedy policy stays the same,
+// assuming the policy changes at most once on each side of the search. low
+// is at least 0 and high at most 0.999; both are accurate to about 1e-4.
+func (m *MDP) OptimalPolicyDiscountRange() (low, high float64) {
+	_, current := m.solveAt(m.Discount)
+	same := func(discount float64) bool {
+		_, policy := m.solveAt(discount)
+		return samePolicy(m.States, policy, current)
+	}
+
+	low = 0
+	if !same(0) {
+		bad, good := 0.0, m.Discount
+		for good-bad > discountSearchTolerance {
+			mid := (bad + good) / 2
+			if same(mid) {
+				good = mid
+			} else {
+				bad = mid
+			}
+		}
+		low = good
+	}
+
+	high = maxSearchDiscount
+	if !same(maxSearchDiscount) {
+		good, bad := m.Discount, maxSearchDiscount
+		for bad-good > discountSearchTolerance {
+			mid := (good + bad) / 2
+			if same(mid) {
+				good = mid
+			} else {
+				bad = mid
+			}
+		}
+		high = good
+	}
+	return low, high
+}
+
+func samePolicy(states []State, a, b map[State]Action) bool {
+	for _, s := range states {
+		if a[s] != b[s] {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectedStepsToTerminal returns the expected number of steps to reach a
+// terminal state (one with no actions) when following policy, solving
+// h(s) = 1 + Σ p(s'|s,π(s)) h(s') with h(terminal) = 0. States from which a
+// terminal state is not reached with probability 1 get +Inf.
+func (m *MDP) ExpectedStepsToTerminal(policy map[State]Action) map[State]float64 {
+	isTerminal := func(s State) bool { return len(m.actionsFor(s)) == 0 }
+
+	// Backward reachability from the terminal states along policy edges.
+	predecessors := make(map[State][]State)
+	for _, s := range m.States {
+		if isTerminal(s) {
+			continue
+		}
+		for _, t := range m.Transitions[s][policy[s]] {
+			if t.Prob > 0 {
+				predecessors[t.NextState] = append(predecessors[t.NextState], s)
+			}
+		}
+	}
+	reaches := make(map[State]bool)
+	var queue []State
+	for _, s := range m.States {
+		if isTerminal(s) {
+			reaches[s] = true
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, p := range predecessors[s] {
+			if !reaches[p] {
+				reaches[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	// Anything that can fall into a state that never terminates is infinite too.
+	infinite := make(map[State]bool)
+	queue = queue[:0]
+	for _, s := range m.States {
+		if !reaches[s] {
+			infinite[s] = true
+			queue = append(queue, s)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, p := range predecessors[s] {
+			if !infinite[p] {
+				infinite[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	steps := make(map[State]float64)
+	for _, s := range m.States {
+		if infinite[s] {
+			steps[s] = math.Inf(1)
+		}
+	}
+	for iter := 0; iter < m.MaxIterations; iter++ {
+		delta := 0.0
+		for _, s := range m.States {
+			if infinite[s] || isTerminal(s) {
+				continue
+			}
+			h := 1.0
+			for _, t := range m.Transitions[s][policy[s]] {
+				if t.Prob > 0 {
+					h += t.Prob * steps[t.NextState]
+				}
+			}
+			delta = math.Max(delta, math.Abs(h-steps[s]))
+			steps[s] = h
+		}
+		if delta < m.Tolerance {
+			break
+		}
+	}
+	return steps
+}
@@ -0,0 +1,180 @@
+package mdplib
+
+import (
+	"maps"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// loopMDP is a single state earning reward 1 forever, so V(γ) = 1/(1-γ).
+func loopMDP(discount float64) *MDP {
+	m := NewMDP([]State{"s"}, discount)
+	m.Tolerance = 1e-12
+	m.MaxIterations = 100000
+	m.AddAction("s", "stay", []Transition{{NextState: "s", Prob: 1, Reward: 1}})
+	return m
+}
+
+func TestDiscountSensitivity(t *testing.T) {
+	m := loopMDP(0.9)
+	got := m.DiscountSensitivity(1e-3)["s"]
+	// dV/dγ = 1/(1-γ)² = 100 at γ = 0.9.
+	if !near(got, 100, 0.05) {
+		t.Errorf("DiscountSensitivity = %v, want about 100", got)
+	}
+	if m.Discount != 0.9 {
+		t.Errorf("Discount = %v after DiscountSensitivity, want 0.9 restored", m.Discount)
+	}
+}
+
+func TestExpectedStepsToTerminal(t *testing.T) {
+	m := NewMDP([]State{"s0", "s1", "coin", "loop", "risky", "T"}, 0.9)
+	m.Tolerance = 1e-12
+	m.MaxIterations = 10000
+	m.AddAction("s0", "go", []Transition{{NextState: "s1", Prob: 1}})
+	m.AddAction("s1", "go", []Transition{{NextState: "T", Prob: 1}})
+	m.AddAction("coin", "flip", []Transition{{NextState: "T", Prob: 0.5}, {NextState: "coin", Prob: 0.5}})
+	m.AddAction("loop", "stay", []Transition{{NextState: "loop", Prob: 1}})
+	m.AddAction("risky", "go", []Transition{{NextState: "T", Prob: 0.9}, {NextState: "loop", Prob: 0.1}})
+	policy := map[State]Action{"s0": "go", "s1": "go", "coin": "flip", "loop": "stay", "risky": "go"}
+
+	steps := m.ExpectedStepsToTerminal(policy)
+	for s, want := range map[State]float64{"s0": 2, "s1": 1, "coin": 2, "T": 0} {
+		if !near(steps[s], want, 1e-9) {
+			t.Errorf("steps(%s) = %v, want %v", s, steps[s], want)
+		}
+	}
+	for _, s := range []State{"loop", "risky"} {
+		if !math.IsInf(steps[s], 1) {
+			t.Errorf("steps(%s) = %v, want +Inf", s, steps[s])
+		}
+	}
+}
+
+// patienceMDP offers 1 now or 2 one step later, so waiting is optimal
+// exactly when 2γ > 1.
+func patienceMDP(discount float64) *MDP {
+	m := NewMDP([]State{"start", "mid", "end"}, discount)
+	m.Tolerance = 1e-9
+	m.AddAction("start", "now", []Transition{{NextState: "end", Prob: 1, Reward: 1}})
+	m.AddAction("start", "wait", []Transition{{NextState: "mid", Prob: 1}})
+	m.AddAction("mid", "go", []Transition{{NextState: "end", Prob: 1, Reward: 2}})
+	return m
+}
+
+func TestOptimalPolicyDiscountRange(t *testing.T) {
+	for _, tc := range []struct {
+		discount, low, high float64
+	}{
+		{0.3, 0, 0.5},
+		{0.8, 0.5, maxSearchDiscount},
+	} {
+		m := patienceMDP(tc.discount)
+		low, high := m.OptimalPolicyDiscountRange()
+		if !near(low, tc.low, 1e-3) || !near(high, tc.high, 1e-3) {
+			t.Errorf("γ = %v: range = [%v, %v], want [%v, %v]", tc.discount, low, high, tc.low, tc.high)
+		}
+		if m.Discount != tc.discount {
+			t.Errorf("Discount = %v after the search, want %v restored", m.Discount, tc.discount)
+		}
+	}
+}
+
+func TestValueIterationWithRewardNoise(t *testing.T) {
+	m := forkMDP()
+	m.ValueIteration()
+	want := maps.Clone(m.ValueFunc)
+	rng := rand.New(rand.NewSource(1))
+
+	zero := map[State]map[Action]float64{"a": {"go": 0}}
+	mean, std := m.ValueIterationWithRewardNoise(zero, 5, rng)
+	for _, s := range m.States {
+		if !near(mean[s], want[s], 1e-9) || std[s] != 0 {
+			t.Errorf("zero noise: %s has mean %v std %v, want %v and 0", s, mean[s], std[s], want[s])
+		}
+	}
+
+	// Noise on b's only reward shifts V(b) one-for-one, so std(V(b)) ≈ 0.5,
+	// and V(a) by 0.5·γ of that.
+	noisy := map[State]map[Action]float64{"b": {"go": 0.5}}
+	mean, std = m.ValueIterationWithRewardNoise(noisy, 4000, rng)
+	if !near(mean["b"], want["b"], 0.05) || !near(std["b"], 0.5, 0.03) {
+		t.Errorf("V(b): mean %v std %v, want about %v and 0.5", mean["b"], std["b"], want["b"])
+	}
+	if !near(std["a"], 0.5*0.9*0.5, 0.02) || std["c"] != 0 {
+		t.Errorf("std(a) = %v, std(c) = %v, want about 0.225 and 0", std["a"], std["c"])
+	}
+	if !maps.Equal(m.ValueFunc, want) {
+		t.Error("ValueFunc was modified")
+	}
+}
+
+func TestValueErrorBound(t *testing.T) {
+	// Stopped after 10 sweeps from 0, V = (1-0.9^10)/0.1 and the last
+	// residual is 0.9^9, so the bound is 0.9^9·0.9/0.1 = 10·0.9^10, which
+	// is exactly the true error |V - 10| on this MDP.
+	m := loopMDP(0.9)
+	m.MaxIterations = 10
+	m.ValueIteration()
+	bound := m.ValueErrorBound()
+	if want := 10 * math.Pow(0.9, 10); !near(bound, want, 1e-9) {
+		t.Errorf("ValueErrorBound = %v, want %v", bound, want)
+	}
+	if trueErr := math.Abs(m.ValueFunc["s"] - 10); trueErr > bound+1e-9 {
+		t.Errorf("true error %v exceeds the bound %v", trueErr, bound)
+	}
+
+	m.PolicyIteration()
+	if b := m.ValueErrorBound(); !math.IsInf(b, 1) {
+		t.Errorf("after PolicyIteration the bound = %v, want +Inf", b)
+	}
+}
+
+func TestEffectiveHorizon(t *testing.T) {
+	for _, c := range []struct{ discount, want float64 }{
+		{0, 1},
+		{0.5, 2},
+		{0.9, 10},
+		{0.99, 100},
+		{1, math.Inf(1)},
+	} {
+		m := NewMDP([]State{"s"}, c.discount)
+		if got := m.EffectiveHorizon(); !near(got, c.want, 1e-9) && got != c.want {
+			t.Errorf("discount %v: EffectiveHorizon = %v, want %v", c.discount, got, c.want)
+		}
+	}
+}
+
+func TestCheckHorizon(t *testing.T) {
+	m := NewMDP([]State{"s"}, 0.99)
+	if err := m.CheckHorizon(); err != nil {
+		t.Errorf("default MaxIterations: %v", err)
+	}
+	m.MaxIterations = 50
+	if err := m.CheckHorizon(); err == nil {
+		t.Error("MaxIterations 50 with a horizon of 100 raised no warning")
+	}
+	m.Discount = 1
+	m.MaxIterations = 1 << 30
+	if err := m.CheckHorizon(); err == nil {
+		t.Error("an undiscounted MDP raised no warning")
+	}
+}
+
+func TestPolicyComparisonAcrossDiscount(t *testing.T) {
+	m := patienceMDP(0.7)
+	m.ValueIteration()
+	m.ExtractPolicy()
+	values := maps.Clone(m.ValueFunc)
+
+	if changed := m.PolicyComparisonAcrossDiscount(0.3, 0.8); len(changed) != 1 || changed[0] != "start" {
+		t.Errorf("0.3 vs 0.8: changed = %v, want [start]", changed)
+	}
+	if changed := m.PolicyComparisonAcrossDiscount(0.6, 0.9); len(changed) != 0 {
+		t.Errorf("0.6 vs 0.9: changed = %v, want none on the same side of 0.5", changed)
+	}
+	if m.Discount != 0.7 || !maps.Equal(m.ValueFunc, values) || m.Policy["start"] != "wait" {
+		t.Errorf("comparison changed the MDP: discount %v, values %v, policy %v", m.Discount, m.ValueFunc, m.Policy)
+	}
+}
@@ -0,0 +1,158 @@
+package mdplib
+
+import (
+	"math"
+)
+
+type Observation string
+
+// Belief is a probability distribution over the base states of an MDP.
+type Belief map[State]float64
+
+// BeliefMDP treats a partially observable problem as an MDP over beliefs.
+// The underlying dynamics come from Base, and Observations holds the
+// observation model O(o | s', a) for landing in s' after taking a.
+type BeliefMDP struct {
+	Base         *MDP
+	Observations map[State]map[Action]map[Observation]float64
+	Grid         []Belief
+	ValueFunc    []float64
+	Policy       []Action
+
+	observationList []Observation
+}
+
+func NewBeliefMDP(base *MDP) *BeliefMDP {
+	return &BeliefMDP{
+		Base:         base,
+		Observations: make(map[State]map[Action]map[Observation]float64),
+	}
+}
+
+func (b *BeliefMDP) AddObservation(next State, action Action, obs Observation, prob float64) {
+	if b.Observations[next] == nil {
+		b.Observations[next] = make(map[Action]map[Observation]float64)
+	}
+	if b.Observations[next][action] == nil {
+		b.Observations[next][action] = make(map[Observation]float64)
+	}
+	b.Observations[next][action][obs] = prob
+	for _, existing := range b.observationList {
+		if existing == obs {
+			return
+		}
+	}
+	b.observationList = append(b.observationList, obs)
+}
+
+// ExpectedReward returns the immediate reward of taking action under belief.
+func (b *BeliefMDP) ExpectedReward(belief Belief, action Action) float64 {
+	r := 0.0
+	for s, p := range belief {
+		for _, t := range b.Base.Transitions[s][action] {
+			r += p * t.Prob * b.Base.reward(t)
+		}
+	}
+	return r
+}
+
+// Update applies Bayes' rule after taking action and seeing obs. It returns
+// the posterior belief and the probability of the observation; the posterior
+// is nil when the observation is impossible under belief.
+func (b *BeliefMDP) Update(belief Belief, action Action, obs Observation) (Belief, float64) {
+	posterior := make(Belief)
+	norm := 0.0
+	for s, p := range belief {
+		for _, t := range b.Base.Transitions[s][action] {
+			w := p * t.Prob * b.Observations[t.NextState][action][obs]
+			if w == 0 {
+				continue
+			}
+			posterior[t.NextState] += w
+			norm += w
+		}
+	}
+	if norm == 0 {
+		return nil, 0
+	}
+	for s := range posterior {
+		posterior[s] /= norm
+	}
+	return posterior, norm
+}
+
+// ValueIteration runs value iteration over the supplied belief grid. Successor
+// beliefs are snapped to their nearest grid point (L1 distance).
+func (b *BeliefMDP) ValueIteration(grid []Belief) {
+	b.Grid = grid
+	b.ValueFunc = make([]float64, len(grid))
+	b.Policy = make([]Action, len(grid))
+	actions := b.actions()
+
+	for i := 0; i < b.Base.MaxIterations; i++ {
+		delta := 0.0
+		newValues := make([]float64, len(grid))
+		for g, belief := range grid {
+			bestValue := math.Inf(-1)
+			bestAction := Action("")
+			for _, a := range actions {
+				v := b.ExpectedReward(belief, a)
+				for _, o := range b.observationList {
+					next, p := b.Update(belief, a, o)
+					if next == nil {
+						continue
+					}
+					v += b.Base.Discount * p * b.ValueFunc[b.nearest(next)]
+				}
+				if v > bestValue {
+					bestValue = v
+					bestAction = a
+				}
+			}
+			if len(actions) == 0 {
+				bestValue = 0
+			}
+			newValues[g] = bestValue
+			b.Policy[g] = bestAction
+			delta = math.Max(delta, math.Abs(bestValue-b.ValueFunc[g]))
+		}
+		b.ValueFunc = newValues
+		if delta < b.Base.Tolerance {
+			break
+		}
+	}
+}
+
+// Value returns the value of the grid point nearest to belief.
+func (b *BeliefMDP) Value(belief Belief) float64 {
+	if len(b.Grid) == 0 {
+		return 0
+	}
+	return b.ValueFunc[b.nearest(belief)]
+}
+
+func (b *BeliefMDP) actions() []Action {
+	var actions []Action
+	for _, s := range b.Base.States {
+		for _, a := range b.Base.Actions[s] {
+			actions = appendIfMissingAction(actions, a)
+		}
+	}
+	return actions
+}
+
+func (b *BeliefMDP) nearest(belief Belief) int {
+	best := 0
+	bestDist := math.Inf(1)
+	for i, point := range b.Grid {
+		dist := 0.0
+		for _, s := range b.Base.States {
+			dist += math.Abs(belief[s] - point[s])
+		}
+		if dist < bestDist {
+			bestDist = dist
+			best = i
+		}
+	}
+	return best
+}
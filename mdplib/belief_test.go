@@ -0,0 +1,70 @@
+package mdplib
+
+import "testing"
+
+// twoStatePOMDP has hidden states A and B that never change. Action "a"
+// earns 1 in A and "b" earns 1 in B; the single observation carries no
+// information.
+func twoStatePOMDP() *BeliefMDP {
+	m := NewMDP([]State{"A", "B"}, 0.5)
+	m.AddAction("A", "a", []Transition{{NextState: "A", Prob: 1, Reward: 1}})
+	m.AddAction("A", "b", []Transition{{NextState: "A", Prob: 1, Reward: 0}})
+	m.AddAction("B", "a", []Transition{{NextState: "B", Prob: 1, Reward: 0}})
+	m.AddAction("B", "b", []Transition{{NextState: "B", Prob: 1, Reward: 1}})
+	b := NewBeliefMDP(m)
+	for _, s := range []State{"A", "B"} {
+		for _, a := range []Action{"a", "b"} {
+			b.AddObservation(s, a, "o", 1)
+		}
+	}
+	return b
+}
+
+func TestBeliefValueIteration(t *testing.T) {
+	b := twoStatePOMDP()
+	b.Base.Tolerance = 1e-10
+	grid := []Belief{{"A": 1}, {"B": 1}, {"A": 0.7, "B": 0.3}}
+	b.ValueIteration(grid)
+
+	// At belief (0.7, 0.3) "a" earns 0.7 every step: 0.7 / (1 - 0.5).
+	if v := b.ValueFunc[2]; !near(v, 1.4, 1e-6) {
+		t.Errorf("value at (0.7, 0.3) = %v, want 1.4", v)
+	}
+	if b.Policy[2] != "a" {
+		t.Errorf("policy at (0.7, 0.3) = %q, want a", b.Policy[2])
+	}
+	if v := b.Value(Belief{"A": 0.1, "B": 0.9}); !near(v, 2, 1e-6) {
+		t.Errorf("Value near B = %v, want 2", v)
+	}
+}
+
+func TestBeliefUpdate(t *testing.T) {
+	m := NewMDP([]State{"A", "B"}, 0.9)
+	m.AddAction("A", "listen", []Transition{{NextState: "A", Prob: 1}})
+	m.AddAction("B", "listen", []Transition{{NextState: "B", Prob: 1}})
+	b := NewBeliefMDP(m)
+	b.AddObservation("A", "listen", "hearA", 0.8)
+	b.AddObservation("A", "listen", "hearB", 0.2)
+	b.AddObservation("B", "listen", "hearA", 0.2)
+	b.AddObservation("B", "listen", "hearB", 0.8)
+
+	posterior, p := b.Update(Belief{"A": 0.5, "B": 0.5}, "listen", "hearA")
+	if !near(p, 0.5, 1e-12) || !near(posterior["A"], 0.8, 1e-12) || !near(posterior["B"], 0.2, 1e-12) {
+		t.Errorf("Update = %v with probability %v, want A 0.8, B 0.2 with 0.5", posterior, p)
+	}
+	if posterior, p := b.Update(Belief{"A": 1}, "listen", "never"); posterior != nil || p != 0 {
+		t.Errorf("impossible observation: got %v, %v", posterior, p)
+	}
+}
+
+func TestBeliefExpectedRewardClipped(t *testing.T) {
+	b := twoStatePOMDP()
+	belief := Belief{"A": 0.7, "B": 0.3}
+	if r := b.ExpectedReward(belief, "a"); !near(r, 0.7, 1e-12) {
+		t.Fatalf("ExpectedReward = %v, want 0.7", r)
+	}
+	b.Base.RewardClip = [2]float64{0, 0.5}
+	if r := b.ExpectedReward(belief, "a"); !near(r, 0.35, 1e-12) {
+		t.Errorf("clipped ExpectedReward = %v, want 0.35", r)
+	}
+}
@@ -0,0 +1,86 @@
+package mdplib
+
+import "fmt"
+
+// ConstrainedValueIteration finds a policy maximizing reward subject to an
+// expected discounted cost of at most budget, where costs[s][a] is charged
+// each time a is taken in s and the expectation is over a uniform start
+// state. It relaxes the constraint with a Lagrange multiplier λ, solving for
+// the reward r - λ·cost, and bisects on λ for the smallest multiplier whose
+// greedy policy fits the budget. Policy is set to that policy, ValueFunc to
+// its (unpenalized) values, and λ is returned; 0 means the unconstrained
+// optimum already fits. The policy is deterministic, so it can fall short of
+// a randomized constrained optimum. A budget no policy found can meet is an
+// error and leaves ValueFunc and Policy untouched.
+func (m *MDP) ConstrainedValueIteration(costs map[State]map[Action]float64, budget float64) (float64, error) {
+	values, last := m.ValueFunc, m.last
+	policy, cost := m.lagrangianPolicy(costs, 0)
+	if cost <= budget {
+		m.setConstrainedPolicy(policy)
+		return 0, nil
+	}
+
+	lo, hi := 0.0, 1.0
+	for {
+		p, c := m.lagrangianPolicy(costs, hi)
+		if c <= budget {
+			policy = p
+			break
+		}
+		if hi > 1e12 {
+			m.ValueFunc, m.last = values, last
+			return 0, fmt.Errorf("ConstrainedValueIteration: budget %g is infeasible, cheapest policy found costs %g", budget, c)
+		}
+		lo, hi = hi, 2*hi
+	}
+
+	for i := 0; i < 60 && hi-lo > 1e-9*hi; i++ {
+		mid := (lo + hi) / 2
+		if p, c := m.lagrangianPolicy(costs, mid); c <= budget {
+			policy, hi = p, mid
+		} else {
+			lo = mid
+		}
+	}
+	m.setConstrainedPolicy(policy)
+	return hi, nil
+}
+
+// lagrangianPolicy solves for the reward r - lambda·cost and returns the
+// greedy policy with its expected discounted cost.
+func (m *MDP) lagrangianPolicy(costs map[State]map[Action]float64, lambda float64) (map[State]Action, float64) {
+	penalized := m.transitionTable()
+	for s, byAction := range penalized {
+		for a, ts := range byAction {
+			for k := range ts {
+				ts[k].Reward -= lambda * costs[s][a]
+			}
+		}
+	}
+	m.ValueIterationModel(penalized)
+	policy := m.greedyPolicyModel(penalized, m.ValueFunc)
+	return policy, m.policyCost(policy, costs)
+}
+
+// policyCost returns the expected discounted cost of policy from a uniform
+// start state.
+func (m *MDP) policyCost(policy map[State]Action, costs map[State]map[Action]float64) float64 {
+	start := make(map[State]float64, len(m.States))
+	for _, s := range m.States {
+		start[s] = 1 / float64(len(m.States))
+	}
+	cost := 0.0
+	for s, d := range m.OccupancyMeasure(policy, start) {
+		if a, ok := policy[s]; ok && a != "" {
+			cost += d * costs[s][a]
+		}
+	}
+	return cost
+}
+
+func (m *MDP) setConstrainedPolicy(policy map[State]Action) {
+	for s, a := range policy {
+		m.Policy[s] = a
+	}
+	m.ValueFunc = m.evaluatePolicy(m.Policy, make(map[State]float64))
+}
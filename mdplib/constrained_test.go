@@ -0,0 +1,37 @@
+package mdplib
+
+import "testing"
+
+func TestConstrainedValueIterationTighteningBudget(t *testing.T) {
+	m := NewMDP([]State{"start", "end"}, 0.9)
+	m.AddAction("start", "fast", []Transition{{NextState: "end", Prob: 1, Reward: 10}})
+	m.AddAction("start", "slow", []Transition{{NextState: "end", Prob: 1, Reward: 4}})
+	costs := map[State]map[Action]float64{"start": {"fast": 5, "slow": 1}}
+	// Starting uniformly, start is occupied half the time: fast costs 2.5
+	// and slow 0.5.
+
+	lambda, err := m.ConstrainedValueIteration(costs, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lambda != 0 || m.Policy["start"] != "fast" || !near(m.ValueFunc["start"], 10, 1e-6) {
+		t.Errorf("loose budget: λ %v policy %q value %v, want 0, fast, 10", lambda, m.Policy["start"], m.ValueFunc["start"])
+	}
+
+	lambda, err = m.ConstrainedValueIteration(costs, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10 - 5λ drops below 4 - λ at λ = 1.5.
+	if !near(lambda, 1.5, 1e-6) || m.Policy["start"] != "slow" || !near(m.ValueFunc["start"], 4, 1e-6) {
+		t.Errorf("tight budget: λ %v policy %q value %v, want 1.5, slow, 4", lambda, m.Policy["start"], m.ValueFunc["start"])
+	}
+
+	m.ValueFunc["start"] = 42
+	if _, err := m.ConstrainedValueIteration(costs, 0.1); err == nil {
+		t.Error("a budget below the cheapest policy was accepted")
+	}
+	if m.ValueFunc["start"] != 42 || m.Policy["start"] != "slow" {
+		t.Errorf("an infeasible budget changed ValueFunc %v or Policy %v", m.ValueFunc, m.Policy)
+	}
+}
@@ -0,0 +1,130 @@
+package mdplib
+
+import (
+	"errors"
+	"math"
+)
+
+// IsDeterministic reports whether every action leads to a single next state
+// with probability 1.
+func (m *MDP) IsDeterministic() bool {
+	for _, s := range m.States {
+		for _, a := range m.Actions[s] {
+			if _, ok := m.DeterministicSuccessor(s, a); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DeterministicSuccessor returns the only next state of (s, a), or false if
+// the action is unknown or stochastic.
+func (m *MDP) DeterministicSuccessor(s State, a Action) (State, bool) {
+	ts := m.Transitions[s][a]
+	if len(ts) != 1 || math.Abs(ts[0].Prob-1) > 1e-9 {
+		return "", false
+	}
+	return ts[0].NextState, true
+}
+
+// TransitionEntropy returns the entropy (in nats) of the next-state
+// distribution of every state-action pair: 0 for deterministic actions and
+// log(k) for a uniform spread over k states. Entries sharing a NextState are
+// combined first.
+func (m *MDP) TransitionEntropy() map[State]map[Action]float64 {
+	entropy := make(map[State]map[Action]float64, len(m.States))
+	for _, s := range m.States {
+		if len(m.Actions[s]) == 0 {
+			continue
+		}
+		entropy[s] = make(map[Action]float64, len(m.Actions[s]))
+		for _, a := range m.Actions[s] {
+			probs := make(map[State]float64)
+			for _, t := range m.Transitions[s][a] {
+				probs[t.NextState] += t.Prob
+			}
+			h := 0.0
+			for _, p := range probs {
+				if p > 0 {
+					h -= p * math.Log(p)
+				}
+			}
+			entropy[s][a] = h
+		}
+	}
+	return entropy
+}
+
+type deterministicEdge struct {
+	next   int // index into States, or -1 for a state with no entry
+	reward float64
+}
+
+// ValueIterationDeterministic is a faster ValueIteration for deterministic
+// MDPs. It resolves every successor to a state index once and then sweeps
+// over flat slices, skipping the map lookups and probability loop.
+func (m *MDP) ValueIterationDeterministic() error {
+	if !m.IsDeterministic() {
+		return errors.New("ValueIterationDeterministic: MDP has stochastic transitions, use ValueIteration")
+	}
+
+	index := make(map[State]int, len(m.States))
+	for i, s := range m.States {
+		index[s] = i
+	}
+	edges := make([][]deterministicEdge, len(m.States))
+	for i, s := range m.States {
+		for _, a := range m.actionsFor(s) {
+			t := m.Transitions[s][a][0]
+			next, ok := index[t.NextState]
+			if !ok {
+				next = -1
+			}
+			edges[i] = append(edges[i], deterministicEdge{next: next, reward: m.reward(t)})
+		}
+	}
+
+	values := make([]float64, len(m.States))
+	for i, s := range m.States {
+		values[i] = m.ValueFunc[s]
+	}
+	newValues := make([]float64, len(m.States))
+
+	m.last = solveInfo{solver: SolverValueIteration, residual: math.Inf(1)}
+	for iter := 0; iter < m.MaxIterations; iter++ {
+		m.last.iterations++
+		delta := 0.0
+		for i := range edges {
+			bestValue := math.Inf(-1)
+			for _, e := range edges[i] {
+				v := e.reward
+				if e.next >= 0 {
+					v += m.Discount * values[e.next]
+				}
+				if v > bestValue {
+					bestValue = v
+				}
+			}
+			if len(edges[i]) == 0 {
+				bestValue = 0 // terminal
+			}
+			newValues[i] = bestValue
+			delta = math.Max(delta, math.Abs(bestValue-values[i]))
+		}
+		values, newValues = newValues, values
+		m.last.residual = delta
+		if m.onIteration != nil {
+			m.onIteration(iter, delta)
+		}
+		if delta < m.Tolerance {
+			break
+		}
+	}
+
+	m.ValueFunc = make(map[State]float64, len(m.States))
+	for i, s := range m.States {
+		m.ValueFunc[s] = values[i]
+	}
+	return nil
+}
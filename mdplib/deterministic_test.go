@@ -0,0 +1,191 @@
+package mdplib
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// gridMDP is an n×n gridworld with states named "r,c". Every move costs 1,
+// except entering one of the terminals, which pays that terminal's reward
+// and ends the episode. A move goes the intended way with probability
+// 1-slip and to either perpendicular side with probability slip/2; moves
+// into a wall or off the grid stay put.
+func gridMDP(n int, walls [][2]int, terminals map[[2]int]float64, slip float64) *MDP {
+	isWall := make(map[[2]int]bool)
+	for _, w := range walls {
+		isWall[w] = true
+	}
+	name := func(c [2]int) State { return State(fmt.Sprintf("%d,%d", c[0], c[1])) }
+	dirs := map[Action][2]int{"up": {-1, 0}, "down": {1, 0}, "left": {0, -1}, "right": {0, 1}}
+	moves := []struct {
+		a             Action
+		perpendicular [2]Action
+	}{
+		{"up", [2]Action{"left", "right"}},
+		{"down", [2]Action{"left", "right"}},
+		{"left", [2]Action{"up", "down"}},
+		{"right", [2]Action{"up", "down"}},
+	}
+
+	var states []State
+	for r := 0; r < n; r++ {
+		for c := 0; c < n; c++ {
+			if !isWall[[2]int{r, c}] {
+				states = append(states, name([2]int{r, c}))
+			}
+		}
+	}
+	m := NewMDP(states, 0.95)
+	for r := 0; r < n; r++ {
+		for c := 0; c < n; c++ {
+			cell := [2]int{r, c}
+			if _, terminal := terminals[cell]; terminal || isWall[cell] {
+				continue
+			}
+			for _, mv := range moves {
+				var ts []Transition
+				for _, o := range []struct {
+					a Action
+					p float64
+				}{{mv.a, 1 - slip}, {mv.perpendicular[0], slip / 2}, {mv.perpendicular[1], slip / 2}} {
+					if o.p == 0 {
+						continue
+					}
+					d := dirs[o.a]
+					next := [2]int{r + d[0], c + d[1]}
+					if next[0] < 0 || next[0] >= n || next[1] < 0 || next[1] >= n || isWall[next] {
+						next = cell
+					}
+					reward, ok := terminals[next]
+					if !ok {
+						reward = -1
+					}
+					merged := false
+					for i := range ts {
+						if ts[i].NextState == name(next) {
+							ts[i].Prob += o.p
+							merged = true
+						}
+					}
+					if !merged {
+						ts = append(ts, Transition{NextState: name(next), Prob: o.p, Reward: reward})
+					}
+				}
+				m.AddAction(name(cell), mv.a, ts)
+			}
+		}
+	}
+	return m
+}
+
+// deterministicGrid is a slip-free 12x12 gridworld with three walls, a goal
+// in the far corner paying 10 and a pit paying -10.
+func deterministicGrid() *MDP {
+	m := gridMDP(12, [][2]int{{3, 3}, {3, 4}, {7, 8}}, map[[2]int]float64{{11, 11}: 10, {5, 5}: -10}, 0)
+	m.Tolerance = 1e-9
+	return m
+}
+
+func TestDeterministicSuccessor(t *testing.T) {
+	m := deterministicGrid()
+	if !m.IsDeterministic() {
+		t.Fatal("slip-free gridworld is not deterministic")
+	}
+	if next, ok := m.DeterministicSuccessor("0,0", "right"); !ok || next != "0,1" {
+		t.Errorf("DeterministicSuccessor(0,0, right) = %q, %v", next, ok)
+	}
+	if next, ok := m.DeterministicSuccessor("0,0", "up"); !ok || next != "0,0" {
+		t.Errorf("DeterministicSuccessor(0,0, up) = %q, %v, want to stay put", next, ok)
+	}
+	if _, ok := m.DeterministicSuccessor("0,0", "jump"); ok {
+		t.Error("DeterministicSuccessor reported an unknown action")
+	}
+
+	m.AddAction("0,0", "right", []Transition{
+		{NextState: "0,1", Prob: 0.8, Reward: -1},
+		{NextState: "1,0", Prob: 0.2, Reward: -1},
+	})
+	if m.IsDeterministic() {
+		t.Error("gridworld with a slippery move reported deterministic")
+	}
+	if _, ok := m.DeterministicSuccessor("0,0", "right"); ok {
+		t.Error("DeterministicSuccessor reported a stochastic action")
+	}
+	if err := m.ValueIterationDeterministic(); err == nil {
+		t.Error("ValueIterationDeterministic accepted a stochastic MDP")
+	}
+}
+
+func TestValueIterationDeterministicMatchesValueIteration(t *testing.T) {
+	want := deterministicGrid()
+	want.ValueIteration()
+	got := deterministicGrid()
+	if err := got.ValueIterationDeterministic(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.ValueFunc) != len(want.States) {
+		t.Fatalf("compact path valued %d states, want all %d", len(got.ValueFunc), len(want.States))
+	}
+	for _, s := range want.States {
+		if !near(got.ValueFunc[s], want.ValueFunc[s], 1e-9) {
+			t.Errorf("V(%s) = %v, want %v", s, got.ValueFunc[s], want.ValueFunc[s])
+		}
+	}
+	// The goal and the pit have no actions; both paths must value them at 0
+	// rather than leaving them out or at -Inf.
+	for _, s := range []State{"11,11", "5,5"} {
+		if len(want.Actions[s]) != 0 {
+			t.Fatalf("%s is not terminal", s)
+		}
+		if got.ValueFunc[s] != 0 || want.ValueFunc[s] != 0 {
+			t.Errorf("terminal V(%s) = %v (compact), %v (ValueIteration), want 0", s, got.ValueFunc[s], want.ValueFunc[s])
+		}
+	}
+}
+
+func BenchmarkValueIteration(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		deterministicGrid().ValueIteration()
+	}
+}
+
+func BenchmarkValueIterationDeterministic(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		deterministicGrid().ValueIterationDeterministic()
+	}
+}
+
+func TestTransitionEntropy(t *testing.T) {
+	m := NewMDP([]State{"a", "b", "c", "d"}, 0.9)
+	m.AddAction("a", "sure", []Transition{{NextState: "b", Prob: 1}})
+	m.AddAction("a", "coin", []Transition{{NextState: "b", Prob: 0.5}, {NextState: "c", Prob: 0.5}})
+	m.AddAction("a", "split", []Transition{
+		{NextState: "b", Prob: 0.25},
+		{NextState: "c", Prob: 0.5},
+		{NextState: "b", Prob: 0.25},
+	})
+	m.AddAction("b", "spread", []Transition{
+		{NextState: "a", Prob: 0.25}, {NextState: "b", Prob: 0.25},
+		{NextState: "c", Prob: 0.25}, {NextState: "d", Prob: 0.25},
+	})
+
+	h := m.TransitionEntropy()
+	for _, c := range []struct {
+		s    State
+		a    Action
+		want float64
+	}{
+		{"a", "sure", 0},
+		{"a", "coin", math.Log(2)},
+		{"a", "split", math.Log(2)}, // duplicate next states are combined
+		{"b", "spread", math.Log(4)},
+	} {
+		if got := h[c.s][c.a]; !near(got, c.want, 1e-12) {
+			t.Errorf("entropy of %s %s = %v, want %v", c.s, c.a, got, c.want)
+		}
+	}
+	if _, ok := h["c"]; ok {
+		t.Error("terminal state c has an entry")
+	}
+}
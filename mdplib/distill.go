@@ -0,0 +1,30 @@
+package mdplib
+
+import "fmt"
+
+// PolicyDistillationDataset turns a solved MDP into supervised training pairs
+// for distilling its policy into a network: for every state with a policy
+// action the input is encode(s) and the target is the one-hot of
+// actionIndex[m.Policy[s]] over len(actionIndex) classes. Terminal states and
+// states without a policy entry are skipped. Run ValueIteration and
+// ExtractPolicy (or PolicyIteration) first.
+func PolicyDistillationDataset(m *MDP, encode func(State) []float64, actionIndex map[Action]int) (inputs, targets [][]float64, err error) {
+	for _, s := range m.States {
+		a, ok := m.Policy[s]
+		if !ok || a == "" || len(m.actionsFor(s)) == 0 {
+			continue
+		}
+		idx, ok := actionIndex[a]
+		if !ok {
+			return nil, nil, fmt.Errorf("PolicyDistillationDataset: action %q of state %q has no index", a, s)
+		}
+		if idx < 0 || idx >= len(actionIndex) {
+			return nil, nil, fmt.Errorf("PolicyDistillationDataset: index %d of action %q out of range [0,%d)", idx, a, len(actionIndex))
+		}
+		target := make([]float64, len(actionIndex))
+		target[idx] = 1
+		inputs = append(inputs, encode(s))
+		targets = append(targets, target)
+	}
+	return inputs, targets, nil
+}
@@ -0,0 +1,52 @@
+package mdplib
+
+import (
+	"math/rand"
+	"testing"
+
+	nn "MDPmakesNN/nnlib"
+)
+
+func TestPolicyDistillationDataset(t *testing.T) {
+	m := NewGridWorld(3, 3, nil, [2]int{2, 2}, -1, 10, 0)
+	m.ValueIteration()
+	m.ExtractPolicy()
+
+	index := make(map[State]int, len(m.States))
+	for i, s := range m.States {
+		index[s] = i
+	}
+	encode := func(s State) []float64 {
+		x := make([]float64, len(m.States))
+		x[index[s]] = 1
+		return x
+	}
+	actionIndex := map[Action]int{Up: 0, Down: 1, Left: 2, Right: 3}
+
+	inputs, targets, err := PolicyDistillationDataset(m, encode, actionIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != len(m.States)-1 {
+		t.Fatalf("got %d pairs, want one per non-goal state", len(inputs))
+	}
+
+	nn.SetSeed(1)
+	net := nn.NewNeuralNetwork([]int{len(m.States), len(actionIndex)}, []nn.ActivationFunc{&nn.Softmax{}})
+	if _, err := net.Fit(inputs, targets, nn.FitConfig{Epochs: 200, LearningRate: 1, Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range m.States {
+		if s == GridState(2, 2) {
+			continue
+		}
+		if class, _ := net.Classify(encode(s)); class != actionIndex[m.Policy[s]] {
+			t.Errorf("net picks action %d in %s, policy says %s", class, s, m.Policy[s])
+		}
+	}
+
+	delete(actionIndex, Right)
+	if _, _, err := PolicyDistillationDataset(m, encode, actionIndex); err == nil {
+		t.Error("a policy action without an index was accepted")
+	}
+}
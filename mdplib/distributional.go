@@ -0,0 +1,114 @@
+package mdplib
+
+import (
+	"math"
+)
+
+// CategoricalValueIteration runs a C51-style distributional value iteration.
+// Each state's return distribution is a categorical over the sorted,
+// evenly spaced support atoms. Each sweep applies the projected Bellman
+// operator: the greedy action is chosen by expected value, and r + γz is
+// projected back onto the atoms by linear interpolation, with values outside
+// the support clamped to its ends. Terminal states (no actions) put all mass
+// on the atom nearest 0. It returns the distribution per state;
+// ValueFunc is left untouched.
+func (m *MDP) CategoricalValueIteration(atoms []float64) map[State][]float64 {
+	n := len(atoms)
+	dists := make(map[State][]float64, len(m.States))
+	if n == 0 {
+		return dists
+	}
+
+	zeroAtom := 0
+	for i, z := range atoms {
+		if math.Abs(z) < math.Abs(atoms[zeroAtom]) {
+			zeroAtom = i
+		}
+	}
+	pointMass := func() []float64 {
+		d := make([]float64, n)
+		d[zeroAtom] = 1
+		return d
+	}
+	distOf := func(s State) []float64 {
+		if d, ok := dists[s]; ok {
+			return d
+		}
+		return pointMass()
+	}
+	for _, s := range m.States {
+		dists[s] = pointMass()
+	}
+
+	for iter := 0; iter < m.MaxIterations; iter++ {
+		delta := 0.0
+		newDists := make(map[State][]float64, len(m.States))
+		for _, s := range m.States {
+			actions := m.actionsFor(s)
+			if len(actions) == 0 {
+				newDists[s] = pointMass()
+				continue
+			}
+
+			var best []float64
+			bestMean := math.Inf(-1)
+			for _, a := range actions {
+				target := make([]float64, n)
+				for _, t := range m.Transitions[s][a] {
+					for j, p := range distOf(t.NextState) {
+						if p == 0 {
+							continue
+						}
+						projectOntoAtoms(atoms, m.reward(t)+m.Discount*atoms[j], t.Prob*p, target)
+					}
+				}
+				mean := distributionMean(atoms, target)
+				if mean > bestMean {
+					bestMean = mean
+					best = target
+				}
+			}
+			newDists[s] = best
+			for j := range best {
+				delta = math.Max(delta, math.Abs(best[j]-dists[s][j]))
+			}
+		}
+		dists = newDists
+		if delta < m.Tolerance {
+			break
+		}
+	}
+	return dists
+}
+
+// distributionMean returns the expected value of a categorical distribution
+// over atoms.
+func distributionMean(atoms, probs []float64) float64 {
+	mean := 0.0
+	for i, p := range probs {
+		mean += p * atoms[i]
+	}
+	return mean
+}
+
+// projectOntoAtoms adds mass at value z to the two neighbouring atoms,
+// split by linear interpolation.
+func projectOntoAtoms(atoms []float64, z, mass float64, out []float64) {
+	n := len(atoms)
+	if n == 1 || z <= atoms[0] {
+		out[0] += mass
+		return
+	}
+	if z >= atoms[n-1] {
+		out[n-1] += mass
+		return
+	}
+	hi := 1
+	for atoms[hi] < z {
+		hi++
+	}
+	lo := hi - 1
+	frac := (z - atoms[lo]) / (atoms[hi] - atoms[lo])
+	out[lo] += mass * (1 - frac)
+	out[hi] += mass * frac
+}
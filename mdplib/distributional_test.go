@@ -0,0 +1,44 @@
+package mdplib
+
+import "testing"
+
+// forkMDP: from a, "go" reaches b (reward 1) or c (reward 3) with equal
+// probability; b reaches c with reward 2; c is terminal.
+func forkMDP() *MDP {
+	m := NewMDP([]State{"a", "b", "c"}, 0.9)
+	m.Tolerance = 1e-9
+	m.AddAction("a", "go", []Transition{{NextState: "b", Prob: 0.5, Reward: 1}, {NextState: "c", Prob: 0.5, Reward: 3}})
+	m.AddAction("b", "go", []Transition{{NextState: "c", Prob: 1, Reward: 2}})
+	return m
+}
+
+func atomSupport(lo, hi float64, n int) []float64 {
+	atoms := make([]float64, n)
+	for i := range atoms {
+		atoms[i] = lo + (hi-lo)*float64(i)/float64(n-1)
+	}
+	return atoms
+}
+
+func TestCategoricalValueIterationMeanMatchesValueIteration(t *testing.T) {
+	atoms := atomSupport(-5, 5, 21)
+	for _, clip := range [][2]float64{{}, {0, 1.5}} {
+		m := forkMDP()
+		m.RewardClip = clip
+		dists := m.CategoricalValueIteration(atoms)
+		m.ValueIteration()
+		for _, s := range m.States {
+			total := 0.0
+			for _, p := range dists[s] {
+				total += p
+			}
+			if !near(total, 1, 1e-9) {
+				t.Errorf("clip %v: distribution of %s sums to %v", clip, s, total)
+			}
+			// Linear projection preserves the mean inside the support.
+			if mean := distributionMean(atoms, dists[s]); !near(mean, m.ValueFunc[s], 1e-6) {
+				t.Errorf("clip %v: mean of %s = %v, ValueIteration gives %v", clip, s, mean, m.ValueFunc[s])
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package mdplib
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel causes wrapped by loader errors, for use with errors.Is. A missing
+// file surfaces as the underlying os error (errors.Is(err, fs.ErrNotExist)).
+var (
+	ErrMalformedRecord    = errors.New("malformed record")
+	ErrInvalidProbability = errors.New("invalid probability")
+	ErrUnsupportedVersion = errors.New("unsupported format version")
+)
+
+// RecordError reports a transition record a loader could not use, with the
+// 1-based line it starts on. Err wraps ErrMalformedRecord or
+// ErrInvalidProbability along with any parse error.
+type RecordError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// ProbabilitySumError reports a state-action pair whose transition
+// probabilities don't sum to 1. It wraps ErrInvalidProbability.
+type ProbabilitySumError struct {
+	State  State
+	Action Action
+	Sum    float64
+}
+
+func (e *ProbabilitySumError) Error() string {
+	return fmt.Sprintf("%v: transitions of %q under %q sum to %v", ErrInvalidProbability, e.State, e.Action, e.Sum)
+}
+
+func (e *ProbabilitySumError) Unwrap() error {
+	return ErrInvalidProbability
+}
+
+// CheckProbabilities returns a *ProbabilitySumError for the first state-action
+// pair whose transition probabilities differ from 1 by more than tolerance.
+// Loaders don't run it, so files can be concatenated and coalesced first.
+func (m *MDP) CheckProbabilities(tolerance float64) error {
+	for _, s := range m.States {
+		for _, a := range m.Actions[s] {
+			sum := 0.0
+			for _, t := range m.Transitions[s][a] {
+				sum += t.Prob
+			}
+			if sum < 1-tolerance || sum > 1+tolerance {
+				return &ProbabilitySumError{State: s, Action: a, Sum: sum}
+			}
+		}
+	}
+	return nil
+}
+
+// checkProb validates a single transition probability
+func checkProb(p float64) error {
+	if !(p >= 0 && p <= 1) {
+		return fmt.Errorf("%w %v, want a value in [0, 1]", ErrInvalidProbability, p)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package mdplib
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// EpsilonGreedy picks an action uniformly at random with probability epsilon
+// and otherwise the action with the highest q, breaking ties uniformly so
+// all-equal estimates don't always favor the same action. Actions are
+// considered in sorted order, so results are reproducible for a seeded rng.
+// It returns "" when q is empty.
+func EpsilonGreedy(q map[Action]float64, epsilon float64, rng *rand.Rand) Action {
+	if len(q) == 0 {
+		return ""
+	}
+	actions := make([]Action, 0, len(q))
+	for a := range q {
+		actions = append(actions, a)
+	}
+	slices.Sort(actions)
+
+	if rng.Float64() < epsilon {
+		return actions[rng.Intn(len(actions))]
+	}
+
+	var best []Action
+	for _, a := range actions {
+		switch {
+		case len(best) == 0 || q[a] > q[best[0]]:
+			best = append(best[:0], a)
+		case q[a] == q[best[0]]:
+			best = append(best, a)
+		}
+	}
+	return best[rng.Intn(len(best))]
+}
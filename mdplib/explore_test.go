@@ -0,0 +1,42 @@
+package mdplib
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEpsilonGreedy(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	q := map[Action]float64{"a": 1, "b": 3, "c": 2}
+
+	for i := 0; i < 1000; i++ {
+		if a := EpsilonGreedy(q, 0, rng); a != "b" {
+			t.Fatalf("epsilon 0 picked %q, want the argmax b", a)
+		}
+	}
+
+	const n = 30000
+	counts := map[Action]int{}
+	for i := 0; i < n; i++ {
+		counts[EpsilonGreedy(q, 1, rng)]++
+	}
+	for a := range q {
+		// Each share is 1/3 with standard error about 0.003.
+		if share := float64(counts[a]) / n; !near(share, 1.0/3, 0.015) {
+			t.Errorf("epsilon 1 picked %q with share %v, want 1/3", a, share)
+		}
+	}
+
+	tied := map[Action]float64{"x": 5, "y": 5}
+	seen := map[Action]bool{}
+	for i := 0; i < 100; i++ {
+		seen[EpsilonGreedy(tied, 0, rng)] = true
+	}
+	if !seen["x"] || !seen["y"] {
+		t.Errorf("all-equal q picked only %v, want ties broken randomly", seen)
+	}
+
+	if a := EpsilonGreedy(nil, 0.5, rng); a != "" {
+		t.Errorf("empty q picked %q, want \"\"", a)
+	}
+}
@@ -0,0 +1,75 @@
+package mdplib
+
+import (
+	"fmt"
+	"math"
+
+	nn "MDPmakesNN/nnlib"
+)
+
+// FittedValueIteration approximates the value function with net, a network
+// mapping encode(s) to a single value, for MDPs too large for a table. Each
+// of the iterations builds the Bellman targets
+// max_a Σ p·(r + γ·V̂(s')) for every non-terminal state from the current
+// network and fits net to them with cfg. Terminal states and successors
+// outside States are worth 0. With huberDelta > 0 the regression uses
+// nn.HuberLoss(huberDelta), so large TD errors (e.g. outlier rewards) get
+// clipped gradients; huberDelta = 0 gives plain least squares (nn.MSELoss).
+// cfg.Loss is ignored. ValueFunc is set to the network's final estimates.
+func (m *MDP) FittedValueIteration(net *nn.NeuralNetwork, encode func(State) []float64, iterations int, huberDelta float64, cfg nn.FitConfig) error {
+	if net.OutputSize() != 1 {
+		return fmt.Errorf("FittedValueIteration: network has %d outputs, want 1", net.OutputSize())
+	}
+	switch {
+	case huberDelta > 0:
+		cfg.Loss = nn.HuberLoss(huberDelta)
+	case huberDelta == 0:
+		cfg.Loss = nn.MSELoss
+	default:
+		return fmt.Errorf("FittedValueIteration: huberDelta must be non-negative, got %v", huberDelta)
+	}
+
+	var states []State
+	var inputs [][]float64
+	for _, s := range m.States {
+		if len(m.actionsFor(s)) > 0 {
+			states = append(states, s)
+			inputs = append(inputs, encode(s))
+		}
+	}
+	if len(states) == 0 {
+		m.ValueFunc = make(map[State]float64)
+		return nil
+	}
+
+	for iter := 0; iter < iterations; iter++ {
+		values := m.approximateValues(net, encode)
+		targets := make([][]float64, len(states))
+		for i, s := range states {
+			best := math.Inf(-1)
+			for _, a := range m.actionsFor(s) {
+				best = math.Max(best, m.qValue(s, a, values))
+			}
+			targets[i] = []float64{best}
+		}
+		if _, err := net.Fit(inputs, targets, cfg); err != nil {
+			return fmt.Errorf("FittedValueIteration: iteration %d: %w", iter, err)
+		}
+	}
+	m.ValueFunc = m.approximateValues(net, encode)
+	return nil
+}
+
+// approximateValues evaluates net on every state, with terminal states
+// pinned to 0
+func (m *MDP) approximateValues(net *nn.NeuralNetwork, encode func(State) []float64) map[State]float64 {
+	values := make(map[State]float64, len(m.States))
+	for _, s := range m.States {
+		if len(m.actionsFor(s)) > 0 {
+			values[s] = net.Predict(encode(s))[0]
+		} else {
+			values[s] = 0
+		}
+	}
+	return values
+}
@@ -0,0 +1,108 @@
+package mdplib
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	nn "MDPmakesNN/nnlib"
+)
+
+// outlierChain is s0 -> s1 -> ... -> s5 -> end with reward 1 per step,
+// except for a corrupt reward of 100 on leaving s2.
+func outlierChain() *MDP {
+	m := NewMDP(nil, 0.5)
+	for i := 0; i <= 5; i++ {
+		m.States = append(m.States, chainState(i))
+	}
+	m.States = append(m.States, "end")
+	for i := 0; i <= 5; i++ {
+		next, reward := chainState(i+1), 1.0
+		if i == 5 {
+			next = "end"
+		}
+		if i == 2 {
+			reward = 100
+		}
+		m.AddAction(chainState(i), "go", []Transition{{NextState: next, Prob: 1, Reward: reward}})
+	}
+	return m
+}
+
+func TestFittedValueIterationHuberResistsOutliers(t *testing.T) {
+	encode := func(s State) []float64 {
+		var i int
+		fmt.Sscanf(string(s), "s%d", &i)
+		return []float64{float64(i) / 5}
+	}
+	fitted := func(huberDelta float64) map[State]float64 {
+		m := outlierChain()
+		net := nn.NewNeuralNetwork([]int{1, 1}, []nn.ActivationFunc{nn.Linear{}})
+		net.Layers[0].Weights[0][0], net.Layers[0].Biases[0] = 0, 0
+		if err := m.FittedValueIteration(net, encode, 30, huberDelta, nn.FitConfig{Epochs: 200, LearningRate: 0.1}); err != nil {
+			t.Fatal(err)
+		}
+		return m.ValueFunc
+	}
+	huber, mse := fitted(1), fitted(0)
+
+	// The true values of s3..s5 don't depend on the outlier, but a line
+	// through the least-squares targets is dragged far off them; the Huber
+	// fit stays close and bounded.
+	exact := outlierChain()
+	exact.ValueIteration()
+	huberErr, mseErr := 0.0, 0.0
+	for i := 3; i <= 5; i++ {
+		s := chainState(i)
+		huberErr = math.Max(huberErr, math.Abs(huber[s]-exact.ValueFunc[s]))
+		mseErr = math.Max(mseErr, math.Abs(mse[s]-exact.ValueFunc[s]))
+	}
+	if huberErr > 0.5 {
+		t.Errorf("Huber: worst error on s3..s5 = %v, want below 0.5 (values %v)", huberErr, huber)
+	}
+	if mseErr < 10 {
+		t.Errorf("MSE: worst error on s3..s5 = %v, expected the outlier to drag it above 10", mseErr)
+	}
+	for s, v := range huber {
+		if math.Abs(v) > 5 {
+			t.Errorf("Huber: V(%s) = %v blew up", s, v)
+		}
+	}
+	if huber["end"] != 0 || mse["end"] != 0 {
+		t.Error("the terminal state was not pinned to 0")
+	}
+}
+
+func TestFittedValueIterationTabular(t *testing.T) {
+	// With one-hot features a linear network is a table, so fitting should
+	// recover the exact values.
+	m := forkMDP()
+	index := make(map[State]int, len(m.States))
+	for i, s := range m.States {
+		index[s] = i
+	}
+	encode := func(s State) []float64 {
+		x := make([]float64, len(m.States))
+		x[index[s]] = 1
+		return x
+	}
+	net := nn.NewNeuralNetwork([]int{len(m.States), 1}, []nn.ActivationFunc{nn.Linear{}})
+	if err := m.FittedValueIteration(net, encode, 20, 1, nn.FitConfig{Epochs: 300, LearningRate: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	exact := forkMDP()
+	exact.ValueIteration()
+	for _, s := range m.States {
+		if !near(m.ValueFunc[s], exact.ValueFunc[s], 1e-3) {
+			t.Errorf("V(%s) = %v, want %v", s, m.ValueFunc[s], exact.ValueFunc[s])
+		}
+	}
+
+	wide := nn.NewNeuralNetwork([]int{len(m.States), 2}, []nn.ActivationFunc{nn.Linear{}})
+	if err := m.FittedValueIteration(wide, encode, 1, 1, nn.FitConfig{Epochs: 1}); err == nil {
+		t.Error("a two-output network was accepted")
+	}
+	if err := m.FittedValueIteration(net, encode, 1, -1, nn.FitConfig{Epochs: 1}); err == nil {
+		t.Error("a negative huberDelta was accepted")
+	}
+}
@@ -0,0 +1,207 @@
+package mdplib
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Grid movement actions used by NewGridWorld.
+const (
+	Up    Action = "up"
+	Down  Action = "down"
+	Left  Action = "left"
+	Right Action = "right"
+)
+
+var gridMoves = []struct {
+	action        Action
+	dr, dc        int
+	perpendicular [2]Action
+}{
+	{Up, -1, 0, [2]Action{Left, Right}},
+	{Down, 1, 0, [2]Action{Left, Right}},
+	{Left, 0, -1, [2]Action{Up, Down}},
+	{Right, 0, 1, [2]Action{Up, Down}},
+}
+
+// GridState names the gridworld cell at row r, column c ("r,c").
+func GridState(r, c int) State {
+	return State(fmt.Sprintf("%d,%d", r, c))
+}
+
+// NewGridWorld builds a rows x cols gridworld with states named by GridState
+// and the four actions Up, Down, Left and Right. Each move succeeds with
+// probability 1-slip and slips to either perpendicular direction with
+// probability slip/2; moves into walls or off the grid leave the agent in
+// place. Entering the goal earns goalReward, every other step earns
+// stepReward, and the goal is terminal. The discount defaults to 0.9.
+func NewGridWorld(rows, cols int, walls [][2]int, goal [2]int, stepReward, goalReward float64, slip float64) *MDP {
+	wall := make(map[[2]int]bool, len(walls))
+	for _, w := range walls {
+		wall[w] = true
+	}
+
+	m := NewMDP(nil, 0.9)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if !wall[[2]int{r, c}] {
+				m.States = append(m.States, GridState(r, c))
+			}
+		}
+	}
+
+	delta := make(map[Action][2]int, len(gridMoves))
+	for _, mv := range gridMoves {
+		delta[mv.action] = [2]int{mv.dr, mv.dc}
+	}
+	move := func(r, c int, a Action) [2]int {
+		nr, nc := r+delta[a][0], c+delta[a][1]
+		if nr < 0 || nr >= rows || nc < 0 || nc >= cols || wall[[2]int{nr, nc}] {
+			return [2]int{r, c}
+		}
+		return [2]int{nr, nc}
+	}
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			cell := [2]int{r, c}
+			if wall[cell] || cell == goal {
+				continue
+			}
+			for _, mv := range gridMoves {
+				outcomes := []struct {
+					a Action
+					p float64
+				}{
+					{mv.action, 1 - slip},
+					{mv.perpendicular[0], slip / 2},
+					{mv.perpendicular[1], slip / 2},
+				}
+
+				var ts []Transition
+				for _, o := range outcomes {
+					if o.p == 0 {
+						continue
+					}
+					next := move(r, c, o.a)
+					ts = mergeTransition(ts, Transition{
+						NextState: GridState(next[0], next[1]),
+						Prob:      o.p,
+						Reward:    gridReward(next, goal, stepReward, goalReward),
+					})
+				}
+				m.AddAction(GridState(r, c), mv.action, ts)
+			}
+		}
+	}
+	return m
+}
+
+func gridReward(cell, goal [2]int, stepReward, goalReward float64) float64 {
+	if cell == goal {
+		return goalReward
+	}
+	return stepReward
+}
+
+// mergeTransition adds t to ts, folding it into an existing entry with the
+// same next state (rewards for the same cell are identical).
+func mergeTransition(ts []Transition, t Transition) []Transition {
+	for i := range ts {
+		if ts[i].NextState == t.NextState {
+			ts[i].Prob += t.Prob
+			return ts
+		}
+	}
+	return append(ts, t)
+}
+
+// LoadFromGridCSV builds a deterministic gridworld from a rectangular CSV
+// grid, one cell per field: a number is the reward for entering that cell,
+// "#" is a wall and "G<reward>" (e.g. "G10") is the terminal goal. Exactly
+// one goal is required. The grid is laid out with NewGridWorld (slip 0) and
+// its rewards replaced by the per-cell ones; the states, actions and
+// transitions are added to m.
+func (m *MDP) LoadFromGridCSV(path string) error {
+	f, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("LoadFromGridCSV: %s is empty", path)
+	}
+
+	rows, cols := len(records), len(records[0])
+	var walls [][2]int
+	var goal [2]int
+	goals := 0
+	rewards := make(map[State]float64)
+	for r, record := range records {
+		for c, field := range record {
+			cell := [2]int{r, c}
+			field = strings.TrimSpace(field)
+			switch {
+			case field == "#":
+				walls = append(walls, cell)
+				continue
+			case strings.HasPrefix(field, "G"):
+				goal = cell
+				goals++
+				field = field[1:]
+			}
+			reward, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return fmt.Errorf("LoadFromGridCSV: cell %d,%d: %w", r, c, err)
+			}
+			rewards[GridState(r, c)] = reward
+		}
+	}
+	if goals != 1 {
+		return fmt.Errorf("LoadFromGridCSV: want exactly one goal cell, found %d", goals)
+	}
+
+	grid := NewGridWorld(rows, cols, walls, goal, 0, 0, 0)
+	for _, s := range grid.States {
+		m.States = appendIfMissing(m.States, s)
+		for _, a := range grid.Actions[s] {
+			ts := grid.Transitions[s][a]
+			for k := range ts {
+				ts[k].Reward = rewards[ts[k].NextState]
+			}
+			m.AddAction(s, a, ts)
+		}
+	}
+	return nil
+}
+
+// ValueGrid lays ValueFunc out as a rows x cols grid for heatmaps, naming
+// cells with stateNamer (GridState when nil). Cells without a value, such as
+// walls, are NaN.
+func (m *MDP) ValueGrid(rows, cols int, stateNamer func(r, c int) State) [][]float64 {
+	if stateNamer == nil {
+		stateNamer = GridState
+	}
+	grid := make([][]float64, rows)
+	for r := range grid {
+		grid[r] = make([]float64, cols)
+		for c := range grid[r] {
+			v, ok := m.ValueFunc[stateNamer(r, c)]
+			if !ok {
+				v = math.NaN()
+			}
+			grid[r][c] = v
+		}
+	}
+	return grid
+}
@@ -0,0 +1,142 @@
+package mdplib
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestNewGridWorld(t *testing.T) {
+	m := NewGridWorld(3, 3, [][2]int{{1, 1}}, [2]int{2, 2}, -1, 10, 0.2)
+	if len(m.States) != 8 {
+		t.Fatalf("len(States) = %d, want 8 cells around the wall", len(m.States))
+	}
+	if len(m.actionsFor(GridState(2, 2))) != 0 {
+		t.Error("goal has actions")
+	}
+
+	// Up from the top-left corner bumps the edge (0.8) and, slipping left,
+	// the other edge (0.1); only the right slip (0.1) moves.
+	want := map[State]float64{GridState(0, 0): 0.9, GridState(0, 1): 0.1}
+	ts := m.Transitions[GridState(0, 0)][Up]
+	if len(ts) != len(want) {
+		t.Fatalf("up from 0,0 = %+v, want %v", ts, want)
+	}
+	for _, tr := range ts {
+		if !near(tr.Prob, want[tr.NextState], 1e-12) || tr.Reward != -1 {
+			t.Errorf("up from 0,0: %+v, want prob %v and reward -1", tr, want[tr.NextState])
+		}
+	}
+
+	// Down from 0,1 runs into the wall at 1,1 and stays put.
+	for _, tr := range m.Transitions[GridState(0, 1)][Down] {
+		if tr.NextState == GridState(1, 1) {
+			t.Error("down from 0,1 entered the wall")
+		}
+	}
+	for _, tr := range m.Transitions[GridState(1, 2)][Down] {
+		if tr.NextState == GridState(2, 2) && (!near(tr.Prob, 0.8, 1e-12) || tr.Reward != 10) {
+			t.Errorf("down from 1,2 into the goal = %+v, want prob 0.8 reward 10", tr)
+		}
+	}
+
+	m.ValueIteration()
+	m.ExtractPolicy()
+	for s, want := range map[State]Action{
+		GridState(1, 2): Down,
+		GridState(2, 1): Right,
+		GridState(0, 2): Down,
+		GridState(2, 0): Right,
+	} {
+		if m.Policy[s] != want {
+			t.Errorf("Policy[%s] = %q, want %q", s, m.Policy[s], want)
+		}
+	}
+}
+
+func TestLoadFromGridCSVMatchesNewGridWorld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grid.csv")
+	grid := "-1, -1, -1\n-1, #, -1\n-1, -1, G10\n"
+	if err := os.WriteFile(path, []byte(grid), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewMDP(nil, 0.9)
+	if err := loaded.LoadFromGridCSV(path); err != nil {
+		t.Fatal(err)
+	}
+	built := NewGridWorld(3, 3, [][2]int{{1, 1}}, [2]int{2, 2}, -1, 10, 0)
+
+	if !slices.Equal(loaded.States, built.States) {
+		t.Fatalf("States = %v, want %v", loaded.States, built.States)
+	}
+	for _, s := range built.States {
+		if !slices.Equal(loaded.Actions[s], built.Actions[s]) {
+			t.Errorf("Actions[%s] = %v, want %v", s, loaded.Actions[s], built.Actions[s])
+		}
+		for _, a := range built.Actions[s] {
+			if !reflect.DeepEqual(loaded.Transitions[s][a], built.Transitions[s][a]) {
+				t.Errorf("%s %s: %+v, want %+v", s, a, loaded.Transitions[s][a], built.Transitions[s][a])
+			}
+		}
+	}
+
+	loaded.ValueIteration()
+	built.ValueIteration()
+	for _, s := range built.States {
+		if !near(loaded.ValueFunc[s], built.ValueFunc[s], 1e-9) {
+			t.Errorf("V(%s) = %v, want %v", s, loaded.ValueFunc[s], built.ValueFunc[s])
+		}
+	}
+}
+
+func TestLoadFromGridCSVErrors(t *testing.T) {
+	for name, grid := range map[string]string{
+		"no goal":   "-1, -1\n-1, -1\n",
+		"two goals": "G1, -1\n-1, G2\n",
+		"bad cell":  "-1, x\n-1, G1\n",
+	} {
+		path := filepath.Join(t.TempDir(), "grid.csv")
+		if err := os.WriteFile(path, []byte(grid), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		m := NewMDP(nil, 0.9)
+		if err := m.LoadFromGridCSV(path); err == nil {
+			t.Errorf("%s: LoadFromGridCSV accepted the grid", name)
+		}
+		if len(m.States) != 0 {
+			t.Errorf("%s: a rejected grid added states %v", name, m.States)
+		}
+	}
+}
+
+func TestValueGrid(t *testing.T) {
+	m := NewGridWorld(2, 3, [][2]int{{0, 1}}, [2]int{1, 2}, -1, 10, 0.1)
+	m.ValueIteration()
+	grid := m.ValueGrid(2, 3, nil)
+
+	if len(grid) != 2 || len(grid[0]) != 3 || len(grid[1]) != 3 {
+		t.Fatalf("ValueGrid shape = %d rows, want 2x3", len(grid))
+	}
+	for r := range grid {
+		for c, v := range grid[r] {
+			if r == 0 && c == 1 {
+				if !math.IsNaN(v) {
+					t.Errorf("wall cell = %v, want NaN", v)
+				}
+				continue
+			}
+			if want := m.ValueFunc[GridState(r, c)]; v != want {
+				t.Errorf("grid[%d][%d] = %v, want ValueFunc[%s] = %v", r, c, v, GridState(r, c), want)
+			}
+		}
+	}
+
+	// A custom namer reads the same values under other names.
+	flipped := m.ValueGrid(3, 2, func(r, c int) State { return GridState(c, r) })
+	if flipped[2][1] != grid[1][2] || flipped[0][0] != grid[0][0] {
+		t.Errorf("transposed namer gave %v, want the transpose of %v", flipped, grid)
+	}
+}
@@ -1,24 +1,29 @@
 package mdplib
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type RawTransition struct {
-	State     string  `json:"state"`
-	Action    string  `json:"action"`
-	NextState string  `json:"next_state"`
-	Prob      float64 `json:"prob"`
-	Reward    float64 `json:"reward"`
+	State     string    `json:"state"`
+	Action    string    `json:"action"`
+	NextState string    `json:"next_state"`
+	Prob      float64   `json:"prob"`
+	Reward    float64   `json:"reward"`
+	Rewards   []float64 `json:"rewards,omitempty"`
 }
 
 func (m *MDP) LoadFromCSV(path string) error {
-	f, err := os.Open(path)
+	f, err := openFile(path)
 	if err != nil {
 		return err
 	}
@@ -32,15 +37,32 @@ func (m *MDP) LoadFromCSV(path string) error {
 		if errors.Is(err, io.EOF) {
 			break
 		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &RecordError{Path: path, Line: parseErr.Line, Err: fmt.Errorf("%w: %w", ErrMalformedRecord, parseErr.Err)}
+		}
 		if err != nil {
 			return err
 		}
+		line, _ := reader.FieldPos(0)
 
+		if len(record) < 5 {
+			return &RecordError{Path: path, Line: line, Err: fmt.Errorf("%w: want at least 5 fields, got %d", ErrMalformedRecord, len(record))}
+		}
 		s := State(record[0])
 		a := Action(record[1])
 		ns := State(record[2])
-		p, _ := strconv.ParseFloat(record[3], 64)
-		r, _ := strconv.ParseFloat(record[4], 64)
+		p, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil {
+			return &RecordError{Path: path, Line: line, Err: fmt.Errorf("%w: prob: %w", ErrMalformedRecord, err)}
+		}
+		if err := checkProb(p); err != nil {
+			return &RecordError{Path: path, Line: line, Err: err}
+		}
+		r, err := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+		if err != nil {
+			return &RecordError{Path: path, Line: line, Err: fmt.Errorf("%w: reward: %w", ErrMalformedRecord, err)}
+		}
 
 		m.States = appendIfMissing(m.States, s)
 		m.States = appendIfMissing(m.States, ns)
@@ -55,17 +77,44 @@ func (m *MDP) LoadFromCSV(path string) error {
 }
 
 func (m *MDP) LoadFromJSON(path string) error {
-	data, err := os.ReadFile(path)
+	f, err := openFile(path)
 	if err != nil {
 		return err
 	}
-
-	var raw []RawTransition
-	err = json.Unmarshal(data, &raw)
+	defer f.Close()
+	data, err := io.ReadAll(f)
 	if err != nil {
 		return err
 	}
 
+	// Decode entry by entry so errors can point at a line.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	malformed := func(offset int64, err error) error {
+		return &RecordError{Path: path, Line: lineAt(data, offset), Err: fmt.Errorf("%w: %w", ErrMalformedRecord, err)}
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		if err == nil {
+			err = errors.New("want a JSON array of transitions")
+		}
+		return malformed(0, err)
+	}
+
+	var raw []RawTransition
+	for dec.More() {
+		offset := dec.InputOffset()
+		var entry RawTransition
+		if err := dec.Decode(&entry); err != nil {
+			return malformed(offset, err)
+		}
+		if err := checkProb(entry.Prob); err != nil {
+			return &RecordError{Path: path, Line: lineAt(data, offset), Err: err}
+		}
+		raw = append(raw, entry)
+	}
+	if _, err := dec.Token(); err != nil {
+		return malformed(dec.InputOffset(), err)
+	}
+
 	for _, entry := range raw {
 		s := State(entry.State)
 		a := Action(entry.Action)
@@ -79,12 +128,50 @@ func (m *MDP) LoadFromJSON(path string) error {
 		}
 		m.Actions[s] = appendIfMissingAction(m.Actions[s], a)
 		m.Transitions[s][a] = append(m.Transitions[s][a], Transition{
-			NextState: ns, Prob: entry.Prob, Reward: entry.Reward,
+			NextState: ns, Prob: entry.Prob, Reward: entry.Reward, Rewards: entry.Rewards,
 		})
 	}
 	return nil
 }
 
+// lineAt returns the 1-based line of data containing offset, skipping the
+// separators the JSON decoder reports offsets before.
+func lineAt(data []byte, offset int64) int {
+	offset = min(offset, int64(len(data)))
+	for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+		offset++
+	}
+	return 1 + bytes.Count(data[:min(offset, int64(len(data)))], []byte("\n"))
+}
+
+// openFile opens path for reading, transparently decompressing .gz files.
+func openFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return gzipFile{zr, f}, nil
+}
+
+// gzipFile closes both the gzip stream and the file underneath it.
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
+
 func appendIfMissing(states []State, s State) []State {
 	for _, existing := range states {
 		if existing == s {
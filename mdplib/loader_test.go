@@ -0,0 +1,100 @@
+package mdplib
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromCSVGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdp.csv.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	zw.Write([]byte("state,action,next_state,prob,reward\na,go,b,0.75,2\na,go,a,0.25,0\n"))
+	zw.Close()
+	f.Close()
+
+	m := NewMDP(nil, 0.9)
+	if err := m.LoadFromCSV(path); err != nil {
+		t.Fatal(err)
+	}
+	ts := m.Transitions["a"]["go"]
+	if len(m.States) != 2 || len(ts) != 2 || ts[0].NextState != "b" || ts[0].Prob != 0.75 || ts[0].Reward != 2 {
+		t.Errorf("loaded States %v and transitions %+v", m.States, ts)
+	}
+}
+
+func tempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoaderErrors(t *testing.T) {
+	header := "state,action,next_state,prob,reward\n"
+	for _, c := range []struct {
+		name, file, content string
+		line                int
+		want                error
+	}{
+		{"csv reward", "m.csv", header + "a,go,b,1,2\na,go,b,0.5,oops\n", 3, ErrMalformedRecord},
+		{"csv fields", "m.csv", header + "a,go,b\n", 2, ErrMalformedRecord},
+		{"csv prob", "m.csv", header + "a,go,b,1,2\nb,go,a,1.5,0\n", 3, ErrInvalidProbability},
+		{"json syntax", "m.json", "[\n  {\"state\": \"a\", \"prob\": 1},\n  {\"state\": }\n]\n", 3, ErrMalformedRecord},
+		{"json prob", "m.json", "[\n  {\"state\": \"a\", \"prob\": -0.1}\n]\n", 2, ErrInvalidProbability},
+	} {
+		path := tempFile(t, c.file, c.content)
+		m := NewMDP(nil, 0.9)
+		var err error
+		if strings.HasSuffix(c.file, ".csv") {
+			err = m.LoadFromCSV(path)
+		} else {
+			err = m.LoadFromJSON(path)
+		}
+
+		var recErr *RecordError
+		if !errors.As(err, &recErr) {
+			t.Errorf("%s: error %v is not a *RecordError", c.name, err)
+			continue
+		}
+		if recErr.Line != c.line || recErr.Path != path {
+			t.Errorf("%s: error at %s:%d, want line %d", c.name, recErr.Path, recErr.Line, c.line)
+		}
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: error %v does not wrap %v", c.name, err, c.want)
+		}
+	}
+
+	err := NewMDP(nil, 0.9).LoadFromCSV(filepath.Join(t.TempDir(), "missing.csv"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: error %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestCheckProbabilities(t *testing.T) {
+	m := NewMDP(nil, 0.9)
+	if err := m.LoadFromCSV(tempFile(t, "m.csv", "state,action,next_state,prob,reward\na,go,b,0.5,1\na,go,a,0.4,0\n")); err != nil {
+		t.Fatal(err)
+	}
+	err := m.CheckProbabilities(1e-9)
+	var sumErr *ProbabilitySumError
+	if !errors.As(err, &sumErr) || sumErr.State != "a" || sumErr.Action != "go" || !near(sumErr.Sum, 0.9, 1e-12) {
+		t.Fatalf("CheckProbabilities = %v, want a *ProbabilitySumError for a go summing to 0.9", err)
+	}
+	if !errors.Is(err, ErrInvalidProbability) {
+		t.Errorf("%v does not wrap ErrInvalidProbability", err)
+	}
+	if err := m.CheckProbabilities(0.2); err != nil {
+		t.Errorf("CheckProbabilities(0.2) = %v, want nil", err)
+	}
+}
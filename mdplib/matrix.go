@@ -0,0 +1,207 @@
+package mdplib
+
+import (
+	"errors"
+	"math"
+)
+
+// PolicyTransitionMatrix returns the Markov chain induced by policy as a
+// dense matrix indexed by position in States: P[i][j] is the probability of
+// moving from States[i] to States[j]. Terminal states (and states without a
+// policy action) are absorbing, and transitions leaving States are dropped.
+func (m *MDP) PolicyTransitionMatrix(policy map[State]Action) [][]float64 {
+	index := make(map[State]int, len(m.States))
+	for i, s := range m.States {
+		index[s] = i
+	}
+
+	p := make([][]float64, len(m.States))
+	for i, s := range m.States {
+		p[i] = make([]float64, len(m.States))
+		ts, ok := m.Transitions[s][policy[s]]
+		if !ok || len(m.actionsFor(s)) == 0 {
+			p[i][i] = 1
+			continue
+		}
+		for _, t := range ts {
+			if j, ok := index[t.NextState]; ok {
+				p[i][j] += t.Prob
+			}
+		}
+	}
+	return p
+}
+
+// NStepTransition returns the distribution over states after n steps of
+// policy from start, i.e. the start row of the n-th power of
+// PolicyTransitionMatrix. States with zero probability are omitted.
+func (m *MDP) NStepTransition(policy map[State]Action, start State, n int) map[State]float64 {
+	p := m.PolicyTransitionMatrix(policy)
+	dist := make([]float64, len(m.States))
+	for i, s := range m.States {
+		if s == start {
+			dist[i] = 1
+		}
+	}
+
+	for step := 0; step < n; step++ {
+		next := make([]float64, len(m.States))
+		for i, mass := range dist {
+			if mass == 0 {
+				continue
+			}
+			for j, prob := range p[i] {
+				next[j] += mass * prob
+			}
+		}
+		dist = next
+	}
+
+	result := make(map[State]float64)
+	for i, mass := range dist {
+		if mass != 0 {
+			result[m.States[i]] = mass
+		}
+	}
+	return result
+}
+
+// StationaryDistribution returns the long-run distribution over States of the
+// chain PolicyTransitionMatrix(policy), found by power iteration from a
+// uniform start under Tolerance and MaxIterations. It iterates the lazy chain
+// (I+P)/2, which has the same stationary distribution but converges on
+// periodic chains too. Terminal states are absorbing, so they collect all
+// the mass of a chain that can reach them.
+func (m *MDP) StationaryDistribution(policy map[State]Action) map[State]float64 {
+	p := m.PolicyTransitionMatrix(policy)
+	n := len(m.States)
+	dist := make([]float64, n)
+	for i := range dist {
+		dist[i] = 1 / float64(n)
+	}
+
+	for iter := 0; iter < m.MaxIterations; iter++ {
+		next := make([]float64, n)
+		for i, mass := range dist {
+			next[i] += mass / 2
+			for j, prob := range p[i] {
+				next[j] += mass * prob / 2
+			}
+		}
+		delta := 0.0
+		for i := range dist {
+			delta = math.Max(delta, math.Abs(next[i]-dist[i]))
+		}
+		dist = next
+		if delta < m.Tolerance {
+			break
+		}
+	}
+
+	result := make(map[State]float64, n)
+	for i, s := range m.States {
+		result[s] = dist[i]
+	}
+	return result
+}
+
+// ActionFrequencies returns the fraction of time steps each action of Policy
+// is taken in the long run: the StationaryDistribution mass of the states
+// choosing it, normalized over the states that take an action. It is empty
+// when terminal states absorb all the mass.
+func (m *MDP) ActionFrequencies() map[Action]float64 {
+	freq := make(map[Action]float64)
+	total := 0.0
+	for s, mass := range m.StationaryDistribution(m.Policy) {
+		a := m.Policy[s]
+		if _, ok := m.Transitions[s][a]; !ok || len(m.actionsFor(s)) == 0 || mass <= 0 {
+			continue
+		}
+		freq[a] += mass
+		total += mass
+	}
+	if total < 1e-12 {
+		return map[Action]float64{}
+	}
+	for a := range freq {
+		freq[a] /= total
+	}
+	return freq
+}
+
+// ExactPolicyEvaluation returns the values of policy by solving
+// (I - γP^π)V = R^π with Gaussian elimination instead of iterating, so the
+// result is exact up to rounding. It costs O(n³) in the number of states and
+// suits small to moderate MDPs. States without a policy action are worth 0,
+// and the system is singular (an error) when γ = 1 and the policy never
+// terminates.
+func (m *MDP) ExactPolicyEvaluation(policy map[State]Action) (map[State]float64, error) {
+	index := make(map[State]int, len(m.States))
+	for i, s := range m.States {
+		index[s] = i
+	}
+
+	n := len(m.States)
+	a := make([][]float64, n)
+	b := make([]float64, n)
+	for i, s := range m.States {
+		a[i] = make([]float64, n)
+		a[i][i] = 1
+		for _, t := range m.Transitions[s][policy[s]] {
+			b[i] += t.Prob * m.reward(t)
+			if j, ok := index[t.NextState]; ok {
+				a[i][j] -= m.Discount * t.Prob
+			}
+		}
+	}
+
+	v, ok := solveLinear(a, b)
+	if !ok {
+		return nil, errors.New("ExactPolicyEvaluation: singular system, the policy's value is unbounded")
+	}
+	values := make(map[State]float64, n)
+	for i, s := range m.States {
+		values[s] = v[i]
+	}
+	return values, nil
+}
+
+// solveLinear solves a·x = b in place by Gaussian elimination with partial
+// pivoting, reporting false for a (numerically) singular a.
+func solveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			if f == 0 {
+				continue
+			}
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+			b[r] -= f * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		sum := b[r]
+		for c := r + 1; c < n; c++ {
+			sum -= a[r][c] * x[c]
+		}
+		x[r] = sum / a[r][r]
+	}
+	return x, true
+}
@@ -0,0 +1,100 @@
+package mdplib
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestNStepTransition(t *testing.T) {
+	// A deterministic chain s0 -> s1 -> ... -> s4, with s4 terminal.
+	var states []State
+	for i := 0; i < 5; i++ {
+		states = append(states, chainState(i))
+	}
+	m := NewMDP(states, 0.9)
+	policy := make(map[State]Action)
+	for i := 0; i < 4; i++ {
+		m.AddAction(chainState(i), "next", []Transition{{NextState: chainState(i + 1), Prob: 1}})
+		policy[chainState(i)] = "next"
+	}
+
+	for n, want := range []State{"s0", "s1", "s2", "s3", "s4", "s4", "s4"} {
+		if got := m.NStepTransition(policy, "s0", n); !maps.Equal(got, map[State]float64{want: 1}) {
+			t.Errorf("%d steps from s0 = %v, want all mass on %s", n, got, want)
+		}
+	}
+	if got := m.NStepTransition(policy, "s2", 0); !maps.Equal(got, map[State]float64{"s2": 1}) {
+		t.Errorf("0 steps from s2 = %v, want s2", got)
+	}
+
+	m.AddAction("s0", "split", []Transition{{NextState: "s1", Prob: 0.5}, {NextState: "s3", Prob: 0.5}})
+	policy["s0"] = "split"
+	got := m.NStepTransition(policy, "s0", 2)
+	if !maps.Equal(got, map[State]float64{"s2": 0.5, "s4": 0.5}) {
+		t.Errorf("2 steps of the split = %v, want s2 and s4 at 0.5", got)
+	}
+}
+
+func TestExactPolicyEvaluationMatchesIterative(t *testing.T) {
+	m := NewGridWorld(4, 4, [][2]int{{1, 1}}, [2]int{3, 3}, -1, 10, 0.2)
+	m.Discount = 0.95
+	m.Tolerance = 1e-12
+	m.MaxIterations = 10000
+	// A deliberately poor policy: always up unless in the top row.
+	policy := make(map[State]Action)
+	for _, s := range m.States {
+		if len(m.actionsFor(s)) > 0 {
+			policy[s] = Up
+			if s[0] == '0' {
+				policy[s] = Right
+			}
+		}
+	}
+
+	exact, err := m.ExactPolicyEvaluation(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iterative := m.evaluatePolicy(policy, make(map[State]float64))
+	for _, s := range m.States {
+		if !near(exact[s], iterative[s], 1e-9) {
+			t.Errorf("V(%s) = %v exactly, %v iteratively", s, exact[s], iterative[s])
+		}
+	}
+	if exact[GridState(3, 3)] != 0 {
+		t.Errorf("terminal goal worth %v, want 0", exact[GridState(3, 3)])
+	}
+
+	// Undiscounted, a policy that never reaches the goal has no solution.
+	loop := NewMDP([]State{"s"}, 1)
+	loop.AddAction("s", "stay", []Transition{{NextState: "s", Prob: 1, Reward: 1}})
+	if _, err := loop.ExactPolicyEvaluation(map[State]Action{"s": "stay"}); err == nil {
+		t.Error("a singular system was solved")
+	}
+}
+
+func TestActionFrequencies(t *testing.T) {
+	// Under the optimal policy A leaves for B half the time and B returns a
+	// quarter of the time, so π(A)·0.5 = π(B)·0.25: π = (1/3, 2/3).
+	m := NewMDP([]State{"A", "B"}, 0.9)
+	m.AddAction("A", "go", []Transition{{NextState: "B", Prob: 0.5, Reward: 1}, {NextState: "A", Prob: 0.5, Reward: 1}})
+	m.AddAction("A", "idle", []Transition{{NextState: "A", Prob: 1}})
+	m.AddAction("B", "back", []Transition{{NextState: "A", Prob: 0.25, Reward: 1}, {NextState: "B", Prob: 0.75, Reward: 1}})
+	m.AddAction("B", "idle", []Transition{{NextState: "B", Prob: 1}})
+	m.ValueIteration()
+	m.ExtractPolicy()
+
+	stationary := m.StationaryDistribution(m.Policy)
+	if !near(stationary["A"], 1.0/3, 1e-5) || !near(stationary["B"], 2.0/3, 1e-5) {
+		t.Fatalf("StationaryDistribution = %v, want A 1/3 and B 2/3", stationary)
+	}
+	freq := m.ActionFrequencies()
+	if len(freq) != 2 || !near(freq["go"], 1.0/3, 1e-5) || !near(freq["back"], 2.0/3, 1e-5) {
+		t.Errorf("ActionFrequencies = %v, want go 1/3 and back 2/3", freq)
+	}
+
+	// Everything ends in the terminal state: no long-run action use.
+	if freq := terminalChain().ActionFrequencies(); len(freq) != 0 {
+		t.Errorf("absorbing chain: ActionFrequencies = %v, want empty", freq)
+	}
+}
@@ -1,6 +1,9 @@
 package mdplib
 
 import (
+	"context"
+	"fmt"
+	"maps"
 	"math"
 )
 
@@ -11,6 +14,10 @@ type Transition struct {
 	NextState State
 	Prob      float64
 	Reward    float64
+
+	// Rewards optionally holds separate reward components (e.g. speed and
+	// safety) for ValueIterationScalarized. Other methods use Reward.
+	Rewards []float64
 }
 
 type MDP struct {
@@ -22,6 +29,23 @@ type MDP struct {
 	Policy        map[State]Action
 	Tolerance     float64
 	MaxIterations int
+	LegalActions  map[State][]Action
+
+	// RewardClip clamps every reward into [RewardClip[0], RewardClip[1]]
+	// during backups, leaving Transitions untouched. It is off unless
+	// RewardClip[0] < RewardClip[1].
+	RewardClip [2]float64
+
+	onIteration func(iter int, delta float64)
+
+	last solveInfo
+}
+
+// solveInfo describes the last solve, for SaveJSON and ValueErrorBound
+type solveInfo struct {
+	solver     string
+	iterations int
+	residual   float64 // largest value change in the final sweep
 }
 
 func NewMDP(states []State, discount float64) *MDP {
@@ -45,27 +69,254 @@ func (m *MDP) AddAction(state State, action Action, transitions []Transition) {
 	m.Transitions[state][action] = transitions
 }
 
+// SetLegalActions restricts the actions considered in state s to the given
+// subset of its known actions. It errors if none of them are known.
+func (m *MDP) SetLegalActions(s State, actions []Action) error {
+	var legal []Action
+	for _, a := range actions {
+		if _, ok := m.Transitions[s][a]; ok {
+			legal = appendIfMissingAction(legal, a)
+		}
+	}
+	if len(legal) == 0 {
+		return fmt.Errorf("SetLegalActions: state %q has no legal actions", s)
+	}
+	if m.LegalActions == nil {
+		m.LegalActions = make(map[State][]Action)
+	}
+	m.LegalActions[s] = legal
+	return nil
+}
+
+func (m *MDP) actionsFor(s State) []Action {
+	if legal, ok := m.LegalActions[s]; ok {
+		return legal
+	}
+	return m.Actions[s]
+}
+
+func (m *MDP) qValue(s State, a Action, values map[State]float64) float64 {
+	return m.modelQValue(m, s, a, values)
+}
+
+func (m *MDP) modelQValue(model TransitionModel, s State, a Action, values map[State]float64) float64 {
+	v := 0.0
+	for _, t := range model.Successors(s, a) {
+		v += t.Prob * (m.reward(t) + m.Discount*values[t.NextState])
+	}
+	return v
+}
+
+// reward returns t.Reward clamped by RewardClip
+func (m *MDP) reward(t Transition) float64 {
+	if lo, hi := m.RewardClip[0], m.RewardClip[1]; lo < hi {
+		return math.Min(math.Max(t.Reward, lo), hi)
+	}
+	return t.Reward
+}
+
+// OnIteration registers a callback invoked after every value iteration sweep
+// with the sweep index and the largest value change in that sweep.
+func (m *MDP) OnIteration(fn func(iter int, delta float64)) {
+	m.onIteration = fn
+}
+
 func (m *MDP) ValueIteration() {
-	for i := 0; i < m.MaxIterations; i++ {
-		delta := 0.0
-		newValues := make(map[State]float64)
+	m.iterate(m, nil)
+}
+
+// ValueIterationCtx runs ValueIteration, checking ctx between sweeps. If ctx
+// is cancelled or its deadline passes first, it returns early with an error
+// wrapping ctx.Err(); ValueFunc then holds the partial values of the sweeps
+// completed so far.
+func (m *MDP) ValueIterationCtx(ctx context.Context) error {
+	if err := m.iterateCtx(ctx, m, nil); err != nil {
+		return fmt.Errorf("ValueIterationCtx: cancelled after %d sweeps, not converged: %w", m.last.iterations, err)
+	}
+	return nil
+}
+
+// ValueIterationTrace runs ValueIteration and returns a copy of ValueFunc
+// after every sweep, for animating or inspecting convergence.
+func (m *MDP) ValueIterationTrace() []map[State]float64 {
+	var trace []map[State]float64
+	m.iterate(m, func(float64) {
+		trace = append(trace, maps.Clone(m.ValueFunc))
+	})
+	return trace
+}
+
+// ValueIterationResiduals runs ValueIteration and returns the max value change
+// of every sweep. On a log scale the slope gives the effective contraction
+// rate.
+func (m *MDP) ValueIterationResiduals() []float64 {
+	var residuals []float64
+	m.iterate(m, func(delta float64) {
+		residuals = append(residuals, delta)
+	})
+	return residuals
+}
+
+// ValueIterationChangedStates runs ValueIteration and returns, for every
+// sweep, the states whose value changed by more than threshold. States that
+// keep showing up long after the rest have settled usually point at a
+// malformed transition, e.g. probabilities that don't sum to 1.
+func (m *MDP) ValueIterationChangedStates(threshold float64) [][]State {
+	var changed [][]State
+	prev := maps.Clone(m.ValueFunc)
+	m.iterate(m, func(float64) {
+		var sweep []State
 		for _, s := range m.States {
-			bestValue := math.Inf(-1)
-			for _, a := range m.Actions[s] {
-				v := 0.0
-				for _, t := range m.Transitions[s][a] {
-					v += t.Prob * (t.Reward + m.Discount*m.ValueFunc[t.NextState])
-				}
-				if v > bestValue {
-					bestValue = v
-				}
+			if math.Abs(m.ValueFunc[s]-prev[s]) > threshold {
+				sweep = append(sweep, s)
 			}
-			newValues[s] = bestValue
-			delta = math.Max(delta, math.Abs(bestValue-m.ValueFunc[s]))
 		}
-		m.ValueFunc = newValues
+		changed = append(changed, sweep)
+		prev = maps.Clone(m.ValueFunc)
+	})
+	return changed
+}
+
+// iterate sweeps with transitions from model until the largest change drops
+// below Tolerance or MaxIterations is reached, calling afterSweep (if non-nil)
+// and then the OnIteration hook after every sweep.
+func (m *MDP) iterate(model TransitionModel, afterSweep func(delta float64)) {
+	m.iterateCtx(context.Background(), model, afterSweep)
+}
+
+// iterateCtx is iterate, stopping with ctx.Err() if ctx is done before a
+// sweep.
+func (m *MDP) iterateCtx(ctx context.Context, model TransitionModel, afterSweep func(delta float64)) error {
+	m.last = solveInfo{solver: SolverValueIteration, residual: math.Inf(1)}
+	for i := 0; i < m.MaxIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		delta := m.sweepModel(model)
+		m.last.iterations++
+		m.last.residual = delta
+		if afterSweep != nil {
+			afterSweep(delta)
+		}
+		if m.onIteration != nil {
+			m.onIteration(i, delta)
+		}
 		if delta < m.Tolerance {
 			break
 		}
 	}
+	return nil
+}
+
+// sweep performs one synchronous Bellman optimality backup over all states and
+// returns the largest change in value.
+func (m *MDP) sweep() float64 {
+	return m.sweepModel(m)
+}
+
+func (m *MDP) sweepModel(model TransitionModel) float64 {
+	delta := 0.0
+	newValues := make(map[State]float64)
+	for _, s := range m.States {
+		actions := m.actionsFor(s)
+		bestValue := 0.0 // terminal states keep value 0
+		if len(actions) > 0 {
+			bestValue = math.Inf(-1)
+		}
+		for _, a := range actions {
+			v := m.modelQValue(model, s, a, m.ValueFunc)
+			if v > bestValue {
+				bestValue = v
+			}
+		}
+		newValues[s] = bestValue
+		delta = math.Max(delta, math.Abs(bestValue-m.ValueFunc[s]))
+	}
+	m.ValueFunc = newValues
+	return delta
+}
+
+// CoalesceTransitions merges transitions of the same state and action that
+// share a NextState, as produced by concatenating transition files. Merged
+// entries sum their probabilities and take the probability-weighted mean of
+// their rewards (and of their Rewards components when every entry has the
+// same number), so the expected reward is preserved. It returns the number
+// of entries removed.
+func (m *MDP) CoalesceTransitions() int {
+	removed := 0
+	for s, byAction := range m.Transitions {
+		for a, ts := range byAction {
+			var merged []Transition
+			index := make(map[State]int, len(ts))
+			weights := make([]float64, 0, len(ts))
+			for _, t := range ts {
+				i, ok := index[t.NextState]
+				if !ok {
+					index[t.NextState] = len(merged)
+					t.Rewards = append([]float64(nil), t.Rewards...)
+					merged = append(merged, t)
+					weights = append(weights, 1)
+					continue
+				}
+				removed++
+				mt := &merged[i]
+				total := mt.Prob + t.Prob
+				wOld, wNew := mt.Prob/total, t.Prob/total
+				if total == 0 {
+					wOld, wNew = weights[i]/(weights[i]+1), 1/(weights[i]+1)
+				}
+				mt.Reward = wOld*mt.Reward + wNew*t.Reward
+				if len(mt.Rewards) == len(t.Rewards) {
+					for k := range mt.Rewards {
+						mt.Rewards[k] = wOld*mt.Rewards[k] + wNew*t.Rewards[k]
+					}
+				} else {
+					mt.Rewards = nil
+				}
+				mt.Prob = total
+				weights[i]++
+			}
+			m.Transitions[s][a] = merged
+		}
+	}
+	return removed
+}
+
+// Size returns the number of states, state-action pairs and transitions.
+func (m *MDP) Size() (numStates, numActions, numTransitions int) {
+	numStates = len(m.States)
+	for _, s := range m.States {
+		numActions += len(m.Actions[s])
+		for _, a := range m.Actions[s] {
+			numTransitions += len(m.Transitions[s][a])
+		}
+	}
+	return numStates, numActions, numTransitions
+}
+
+// TransitionCount returns the number of stored transitions.
+func (m *MDP) TransitionCount() int {
+	_, _, n := m.Size()
+	return n
+}
+
+// RewardStatistics returns the min, max and unweighted mean of every stored
+// Transition.Reward, or zeros if there are no transitions.
+func (m *MDP) RewardStatistics() (min, max, mean float64) {
+	count := 0
+	min, max = math.Inf(1), math.Inf(-1)
+	for _, s := range m.States {
+		for _, a := range m.Actions[s] {
+			for _, t := range m.Transitions[s][a] {
+				min = math.Min(min, t.Reward)
+				max = math.Max(max, t.Reward)
+				mean += t.Reward
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return 0, 0, 0
+	}
+	return min, max, mean / float64(count)
 }
@@ -0,0 +1,307 @@
+package mdplib
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// terminalChain is start -> goal with reward 1, where goal has no actions.
+func terminalChain() *MDP {
+	m := NewMDP([]State{"start", "goal"}, 0.9)
+	m.AddAction("start", "go", []Transition{{NextState: "goal", Prob: 1, Reward: 1}})
+	return m
+}
+
+func TestTerminalStatesValuedZero(t *testing.T) {
+	m := terminalChain()
+	m.ValueIteration()
+	if v := m.ValueFunc["goal"]; v != 0 {
+		t.Errorf("ValueIteration: terminal value = %v, want 0", v)
+	}
+	if v := m.ValueFunc["start"]; math.Abs(v-1) > 1e-9 {
+		t.Errorf("ValueIteration: start value = %v, want 1", v)
+	}
+
+	values, _ := NewSolver(m).Solve(m.Discount)
+	if values["goal"] != 0 || math.Abs(values["start"]-1) > 1e-9 {
+		t.Errorf("Solver.Solve: values = %v, want goal 0 and start 1", values)
+	}
+
+	m = terminalChain()
+	if err := m.ValueIterationDeterministic(); err != nil {
+		t.Fatal(err)
+	}
+	if m.ValueFunc["goal"] != 0 || math.Abs(m.ValueFunc["start"]-1) > 1e-9 {
+		t.Errorf("ValueIterationDeterministic: values = %v, want goal 0 and start 1", m.ValueFunc)
+	}
+}
+
+func near(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+// choiceMDP offers "best" (reward 10) and "ok" (reward 5) from start to the
+// terminal state end.
+func choiceMDP() *MDP {
+	m := NewMDP([]State{"start", "end"}, 0.9)
+	m.AddAction("start", "best", []Transition{{NextState: "end", Prob: 1, Reward: 10}})
+	m.AddAction("start", "ok", []Transition{{NextState: "end", Prob: 1, Reward: 5}})
+	m.AddAction("start", "bad", []Transition{{NextState: "end", Prob: 1, Reward: 1}})
+	return m
+}
+
+func TestSetLegalActionsMasksOptimalAction(t *testing.T) {
+	m := choiceMDP()
+	m.ValueIteration()
+	m.ExtractPolicy()
+	if m.Policy["start"] != "best" {
+		t.Fatalf("unmasked policy = %q, want best", m.Policy["start"])
+	}
+
+	if err := m.SetLegalActions("start", []Action{"ok", "bad"}); err != nil {
+		t.Fatal(err)
+	}
+	m.ValueIteration()
+	m.ExtractPolicy()
+	if m.Policy["start"] != "ok" {
+		t.Errorf("masked policy = %q, want ok", m.Policy["start"])
+	}
+	if !near(m.ValueFunc["start"], 5, 1e-9) {
+		t.Errorf("masked value = %v, want 5", m.ValueFunc["start"])
+	}
+}
+
+func TestSetLegalActionsRejectsEmptyMask(t *testing.T) {
+	m := choiceMDP()
+	if err := m.SetLegalActions("start", []Action{"unknown"}); err == nil {
+		t.Error("SetLegalActions accepted a mask with no known actions")
+	}
+	if _, ok := m.LegalActions["start"]; ok {
+		t.Error("a rejected mask was stored")
+	}
+}
+
+func TestOnIterationCalledEverySweep(t *testing.T) {
+	m := NewMDP([]State{"s"}, 0.5)
+	m.AddAction("s", "stay", []Transition{{NextState: "s", Prob: 1, Reward: 1}})
+	var iters []int
+	var deltas []float64
+	m.OnIteration(func(iter int, delta float64) {
+		iters = append(iters, iter)
+		deltas = append(deltas, delta)
+	})
+	m.ValueIteration()
+
+	// Sweep i changes the value by 0.5^i; value iteration stops after the
+	// first sweep whose change is below Tolerance.
+	sweeps := 1
+	for d := 1.0; d >= m.Tolerance; d /= 2 {
+		sweeps++
+	}
+	if len(iters) != sweeps {
+		t.Errorf("callback ran %d times, want once per sweep (%d)", len(iters), sweeps)
+	}
+	for i, iter := range iters {
+		if iter != i {
+			t.Fatalf("iteration indices = %v, want 0, 1, 2, ...", iters)
+		}
+		if i > 0 && deltas[i] >= deltas[i-1] {
+			t.Errorf("delta %d = %v did not decrease from %v", i, deltas[i], deltas[i-1])
+		}
+	}
+	if last := deltas[len(deltas)-1]; last >= m.Tolerance {
+		t.Errorf("final delta %v is not below Tolerance", last)
+	}
+}
+
+func TestSize(t *testing.T) {
+	m := choiceMDP()
+	m.AddAction("end", "stay", []Transition{{NextState: "end", Prob: 0.5}, {NextState: "start", Prob: 0.5}})
+	states, actions, transitions := m.Size()
+	if states != 2 || actions != 4 || transitions != 5 {
+		t.Errorf("Size = (%d, %d, %d), want (2, 4, 5)", states, actions, transitions)
+	}
+	if got := m.TransitionCount(); got != 5 {
+		t.Errorf("TransitionCount = %d, want 5", got)
+	}
+}
+
+func TestValueIterationTrace(t *testing.T) {
+	m := choiceMDP()
+	m.AddAction("end", "stay", []Transition{{NextState: "end", Prob: 1, Reward: 1}})
+	sweeps := 0
+	m.OnIteration(func(int, float64) { sweeps++ })
+	trace := m.ValueIterationTrace()
+
+	if len(trace) != sweeps {
+		t.Fatalf("len(trace) = %d, want %d sweeps", len(trace), sweeps)
+	}
+	last := trace[len(trace)-1]
+	for _, s := range m.States {
+		if last[s] != m.ValueFunc[s] {
+			t.Errorf("last trace entry[%s] = %v, want converged %v", s, last[s], m.ValueFunc[s])
+		}
+	}
+	if trace[0]["end"] == last["end"] {
+		t.Error("trace entries alias the final ValueFunc")
+	}
+}
+
+func TestRewardStatistics(t *testing.T) {
+	m := choiceMDP()
+	m.AddAction("end", "stay", []Transition{{NextState: "end", Prob: 0.5, Reward: -4}, {NextState: "start", Prob: 0.5}})
+	lo, hi, mean := m.RewardStatistics()
+	// Rewards 10, 5, 1, -4 and 0.
+	if lo != -4 || hi != 10 || !near(mean, 2.4, 1e-12) {
+		t.Errorf("RewardStatistics = (%v, %v, %v), want (-4, 10, 2.4)", lo, hi, mean)
+	}
+
+	if lo, hi, mean := NewMDP([]State{"s"}, 0.9).RewardStatistics(); lo != 0 || hi != 0 || mean != 0 {
+		t.Errorf("empty MDP: RewardStatistics = (%v, %v, %v), want zeros", lo, hi, mean)
+	}
+}
+
+func TestValueIterationResiduals(t *testing.T) {
+	m := forkMDP()
+	m.AddAction("c", "stay", []Transition{{NextState: "c", Prob: 1, Reward: 0.5}})
+	sweeps := 0
+	m.OnIteration(func(int, float64) { sweeps++ })
+	residuals := m.ValueIterationResiduals()
+	if len(residuals) != sweeps {
+		t.Fatalf("len(residuals) = %d, want %d sweeps", len(residuals), sweeps)
+	}
+	for i := 1; i < len(residuals); i++ {
+		if residuals[i] > residuals[i-1] {
+			t.Errorf("residual %d = %v rose from %v", i, residuals[i], residuals[i-1])
+		}
+	}
+	if last := residuals[len(residuals)-1]; last >= m.Tolerance {
+		t.Errorf("final residual %v is not below Tolerance %v", last, m.Tolerance)
+	}
+}
+
+func TestValueIterationChangedStates(t *testing.T) {
+	// "leak" has transition probabilities summing to 1.05, so its value
+	// diverges while the well-formed states settle.
+	m := NewMDP([]State{"a", "b", "leak"}, 0.99)
+	m.MaxIterations = 50
+	m.AddAction("a", "go", []Transition{{NextState: "b", Prob: 1, Reward: 1}})
+	m.AddAction("leak", "stay", []Transition{{NextState: "leak", Prob: 1.05, Reward: 1}})
+
+	changed := m.ValueIterationChangedStates(1e-6)
+	if len(changed) != 50 {
+		t.Fatalf("got %d sweeps, want MaxIterations = 50", len(changed))
+	}
+	for i, sweep := range changed[2:] {
+		if !slices.Equal(sweep, []State{"leak"}) {
+			t.Errorf("sweep %d changed %v, want only leak", i+2, sweep)
+		}
+	}
+	if !slices.Contains(changed[0], "a") {
+		t.Errorf("first sweep changed %v, want a among them", changed[0])
+	}
+}
+
+func TestRewardClipChangesPolicy(t *testing.T) {
+	// "gamble" has one outlier reward worth taking unclipped; "steady" wins
+	// once rewards are clamped to [-10, 10].
+	m := NewMDP([]State{"start", "end"}, 0.9)
+	m.AddAction("start", "gamble", []Transition{
+		{NextState: "end", Prob: 0.1, Reward: 1000},
+		{NextState: "end", Prob: 0.9, Reward: -5},
+	})
+	m.AddAction("start", "steady", []Transition{{NextState: "end", Prob: 1, Reward: 3}})
+
+	m.ValueIteration()
+	m.ExtractPolicy()
+	if m.Policy["start"] != "gamble" || !near(m.ValueFunc["start"], 95.5, 1e-9) {
+		t.Fatalf("unclipped: policy %q value %v, want gamble and 95.5", m.Policy["start"], m.ValueFunc["start"])
+	}
+
+	m.RewardClip = [2]float64{-10, 10}
+	m.ValueIteration()
+	m.ExtractPolicy()
+	if m.Policy["start"] != "steady" || !near(m.ValueFunc["start"], 3, 1e-9) {
+		t.Errorf("clipped: policy %q value %v, want steady and 3", m.Policy["start"], m.ValueFunc["start"])
+	}
+	if m.Transitions["start"]["gamble"][0].Reward != 1000 {
+		t.Error("clipping modified the stored reward")
+	}
+}
+
+func TestCoalesceTransitions(t *testing.T) {
+	// The same a -> b outcome split across two loaded files.
+	m := NewMDP([]State{"a", "b"}, 0.9)
+	m.AddAction("a", "go", []Transition{
+		{NextState: "b", Prob: 0.3, Reward: 2, Rewards: []float64{2, 0}},
+		{NextState: "a", Prob: 0.5, Reward: 1},
+		{NextState: "b", Prob: 0.2, Reward: 4, Rewards: []float64{4, 1}},
+	})
+	m.AddAction("b", "stay", []Transition{{NextState: "b", Prob: 1, Reward: 1}})
+	m.Tolerance = 1e-12
+	m.ValueIteration()
+	before := m.ValueFunc["a"]
+
+	if removed := m.CoalesceTransitions(); removed != 1 {
+		t.Errorf("CoalesceTransitions removed %d entries, want 1", removed)
+	}
+	ts := m.Transitions["a"]["go"]
+	if len(ts) != 2 {
+		t.Fatalf("a go = %+v, want one entry per next state", ts)
+	}
+	b := ts[0]
+	if b.NextState != "b" || !near(b.Prob, 0.5, 1e-12) || !near(b.Reward, 2.8, 1e-12) {
+		t.Errorf("merged entry = %+v, want b with prob 0.5 and reward 2.8", b)
+	}
+	if len(b.Rewards) != 2 || !near(b.Rewards[0], 2.8, 1e-12) || !near(b.Rewards[1], 0.4, 1e-12) {
+		t.Errorf("merged Rewards = %v, want [2.8 0.4]", b.Rewards)
+	}
+
+	// V(b) = 10 and V(a) = (0.5·(2.8+9) + 0.5) / (1 - 0.45).
+	m.ValueIteration()
+	want := (0.5*(2.8+9) + 0.5) / 0.55
+	if !near(m.ValueFunc["a"], want, 1e-9) || !near(before, want, 1e-9) {
+		t.Errorf("V(a) = %v after and %v before coalescing, want %v", m.ValueFunc["a"], before, want)
+	}
+	if m.CoalesceTransitions() != 0 {
+		t.Error("a second CoalesceTransitions still found duplicates")
+	}
+}
+
+func TestValueIterationCtxCancel(t *testing.T) {
+	m := NewMDP([]State{"s"}, 0.9)
+	m.AddAction("s", "stay", []Transition{{NextState: "s", Prob: 1, Reward: 1}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.OnIteration(func(iter int, _ float64) {
+		if iter == 0 {
+			cancel()
+		}
+	})
+
+	err := m.ValueIterationCtx(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want one wrapping context.Canceled", err)
+	}
+	if m.last.iterations != 1 || m.ValueFunc["s"] != 1 {
+		t.Errorf("stopped after %d sweeps with V(s) = %v, want 1 sweep and the partial value 1", m.last.iterations, m.ValueFunc["s"])
+	}
+
+	m.OnIteration(nil)
+	if err := m.ValueIterationCtx(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !near(m.ValueFunc["s"], 10, 1e-5) {
+		t.Errorf("uncancelled V(s) = %v, want 10", m.ValueFunc["s"])
+	}
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Unix(0, 0))
+	defer cancelExpired()
+	if err := m.ValueIterationCtx(expired); !errors.Is(err, context.DeadlineExceeded) || m.last.iterations != 0 {
+		t.Errorf("expired deadline: error %v after %d sweeps, want DeadlineExceeded before any sweep", err, m.last.iterations)
+	}
+}
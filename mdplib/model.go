@@ -0,0 +1,94 @@
+package mdplib
+
+import (
+	"fmt"
+	"maps"
+)
+
+// TransitionModel supplies the outcomes of taking action a in state s. It lets
+// value iteration query transitions on demand, e.g. computing them from a
+// factored representation, instead of reading the stored Transitions map.
+type TransitionModel interface {
+	Successors(s State, a Action) []Transition
+}
+
+// Successors returns the stored transitions, so an MDP is its own
+// TransitionModel.
+func (m *MDP) Successors(s State, a Action) []Transition {
+	return m.Transitions[s][a]
+}
+
+// transitionTable is a TransitionModel backed by a plain map, used for
+// perturbed copies of an MDP's transitions.
+type transitionTable map[State]map[Action][]Transition
+
+func (t transitionTable) Successors(s State, a Action) []Transition {
+	return t[s][a]
+}
+
+// transitionTable copies the transitions of every state's available actions
+// so their rewards can be modified without touching the MDP.
+func (m *MDP) transitionTable() transitionTable {
+	table := make(transitionTable, len(m.States))
+	for _, s := range m.States {
+		table[s] = make(map[Action][]Transition)
+		for _, a := range m.actionsFor(s) {
+			table[s][a] = append([]Transition(nil), m.Transitions[s][a]...)
+		}
+	}
+	return table
+}
+
+// ValueIterationModel runs value iteration over m.States and the actions
+// available in each state, taking transitions from model rather than
+// m.Transitions. Discount, Tolerance, MaxIterations and the OnIteration hook
+// apply as in ValueIteration.
+func (m *MDP) ValueIterationModel(model TransitionModel) {
+	m.iterate(model, nil)
+}
+
+// ValueIterationScalarized runs value iteration on the weighted sum of each
+// transition's Rewards components, then sets Policy greedily under the same
+// scalarized rewards. Transitions without Rewards use Reward as is; a
+// component count that doesn't match weights is an error.
+func (m *MDP) ValueIterationScalarized(weights []float64) error {
+	scalarized := m.transitionTable()
+	for s, byAction := range scalarized {
+		for a, ts := range byAction {
+			for k, t := range ts {
+				if t.Rewards == nil {
+					continue
+				}
+				if len(t.Rewards) != len(weights) {
+					return fmt.Errorf("ValueIterationScalarized: transition %q -%s-> %q has %d reward components, want %d", s, a, t.NextState, len(t.Rewards), len(weights))
+				}
+				ts[k].Reward = 0
+				for i, w := range weights {
+					ts[k].Reward += w * t.Rewards[i]
+				}
+			}
+		}
+	}
+
+	m.ValueIterationModel(scalarized)
+	maps.Copy(m.Policy, m.greedyPolicyModel(scalarized, m.ValueFunc))
+	return nil
+}
+
+// ValueIterationWithExplorationBonus runs value iteration with bonus(s')
+// added to the reward of every transition into s', e.g. an inverse visit
+// count, so the values and the greedy Policy it sets favor states the bonus
+// marks as under-explored.
+func (m *MDP) ValueIterationWithExplorationBonus(bonus func(State) float64) {
+	shaped := m.transitionTable()
+	for _, byAction := range shaped {
+		for _, ts := range byAction {
+			for k := range ts {
+				ts[k].Reward += bonus(ts[k].NextState)
+			}
+		}
+	}
+
+	m.ValueIterationModel(shaped)
+	maps.Copy(m.Policy, m.greedyPolicyModel(shaped, m.ValueFunc))
+}
@@ -0,0 +1,126 @@
+package mdplib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// lazyChain computes the transitions of an n-state chain on demand: "go"
+// from s<i> moves to s<i+1> with probability 0.8 (reward 1) and stays put
+// otherwise; s<n-1> is terminal.
+type lazyChain struct {
+	n     int
+	calls int
+}
+
+func (c *lazyChain) Successors(s State, a Action) []Transition {
+	c.calls++
+	i, _ := strconv.Atoi(strings.TrimPrefix(string(s), "s"))
+	return []Transition{
+		{NextState: chainState(i + 1), Prob: 0.8, Reward: 1},
+		{NextState: s, Prob: 0.2},
+	}
+}
+
+func chainState(i int) State {
+	return State(fmt.Sprintf("s%d", i))
+}
+
+func TestValueIterationModelMatchesMaterialized(t *testing.T) {
+	model := &lazyChain{n: 6}
+	var states []State
+	for i := 0; i < model.n; i++ {
+		states = append(states, chainState(i))
+	}
+
+	materialized := NewMDP(states, 0.9)
+	lazy := NewMDP(states, 0.9)
+	for _, s := range states[:model.n-1] {
+		materialized.AddAction(s, "go", model.Successors(s, "go"))
+		lazy.Actions[s] = []Action{"go"}
+	}
+	model.calls = 0
+
+	materialized.ValueIteration()
+	lazy.ValueIterationModel(model)
+	if model.calls == 0 {
+		t.Fatal("ValueIterationModel never queried the model")
+	}
+	for _, s := range states {
+		if !near(lazy.ValueFunc[s], materialized.ValueFunc[s], 1e-9) {
+			t.Errorf("V(%s) = %v, materialized gives %v", s, lazy.ValueFunc[s], materialized.ValueFunc[s])
+		}
+	}
+	if lazy.ValueFunc[states[0]] <= lazy.ValueFunc[states[model.n-2]] {
+		t.Error("values should grow with the distance to the terminal state")
+	}
+}
+
+// routeMDP offers a fast but risky route and a slow but safe one; rewards
+// are (speed, safety).
+func routeMDP() *MDP {
+	m := NewMDP([]State{"start", "end"}, 0.9)
+	m.AddAction("start", "fast", []Transition{{NextState: "end", Prob: 1, Reward: 99, Rewards: []float64{10, -5}}})
+	m.AddAction("start", "safe", []Transition{{NextState: "end", Prob: 1, Reward: 99, Rewards: []float64{2, 3}}})
+	return m
+}
+
+func TestValueIterationScalarized(t *testing.T) {
+	for _, tc := range []struct {
+		weights []float64
+		want    Action
+		value   float64
+	}{
+		{[]float64{1, 0}, "fast", 10},
+		{[]float64{0.2, 0.8}, "safe", 2.8},
+		{[]float64{0.5, 0.5}, "fast", 2.5},
+	} {
+		m := routeMDP()
+		if err := m.ValueIterationScalarized(tc.weights); err != nil {
+			t.Fatal(err)
+		}
+		if m.Policy["start"] != tc.want || !near(m.ValueFunc["start"], tc.value, 1e-9) {
+			t.Errorf("weights %v: policy %q value %v, want %q and %v", tc.weights, m.Policy["start"], m.ValueFunc["start"], tc.want, tc.value)
+		}
+	}
+
+	m := routeMDP()
+	if err := m.ValueIterationScalarized([]float64{1}); err == nil {
+		t.Error("a weight vector of the wrong length was accepted")
+	}
+	m.ValueIteration()
+	if m.ValueFunc["start"] != 99 {
+		t.Errorf("plain ValueIteration = %v, want the scalar Reward 99", m.ValueFunc["start"])
+	}
+}
+
+func TestValueIterationWithExplorationBonus(t *testing.T) {
+	// "main" pays slightly more than the detour through "side".
+	m := NewMDP([]State{"start", "side", "end"}, 0.9)
+	m.AddAction("start", "main", []Transition{{NextState: "end", Prob: 1, Reward: 2}})
+	m.AddAction("start", "detour", []Transition{{NextState: "side", Prob: 1, Reward: 1}})
+	m.AddAction("side", "go", []Transition{{NextState: "end", Prob: 1}})
+
+	m.ValueIterationWithExplorationBonus(func(State) float64 { return 0 })
+	if m.Policy["start"] != "main" {
+		t.Fatalf("without a bonus the policy is %q, want main", m.Policy["start"])
+	}
+
+	m.ValueIterationWithExplorationBonus(func(s State) float64 {
+		if s == "side" {
+			return 1.5
+		}
+		return 0
+	})
+	if m.Policy["start"] != "detour" {
+		t.Errorf("with a bonus on side the policy is %q, want detour", m.Policy["start"])
+	}
+	if !near(m.ValueFunc["start"], 2.5, 1e-9) {
+		t.Errorf("V(start) = %v, want 1 + 1.5 = 2.5", m.ValueFunc["start"])
+	}
+	if m.Transitions["start"]["detour"][0].Reward != 1 {
+		t.Error("the bonus was written into the stored transitions")
+	}
+}
@@ -0,0 +1,71 @@
+package mdplib
+
+import (
+	"math"
+	"math/rand"
+)
+
+// StepFunc samples one environment transition, returning the next state, the
+// reward and whether the episode has ended.
+type StepFunc func(s State, a Action) (next State, reward float64, done bool)
+
+// MonteCarloQ estimates Q(s, a) by rolling out episodes that take a in s and
+// then follow Policy, returning the mean discounted return and its standard
+// error. States without a policy action pick a legal action uniformly with
+// rng; the episode ends early in a state with no actions at all.
+// Episodes are cut off after MaxIterations steps.
+func (m *MDP) MonteCarloQ(s State, a Action, episodes int, rng *rand.Rand, step StepFunc) (mean, stderr float64) {
+	if episodes <= 0 {
+		return 0, 0
+	}
+
+	returns := make([]float64, episodes)
+	for ep := range returns {
+		state, action := s, a
+		var rewards []float64
+		for t := 0; t < m.MaxIterations; t++ {
+			next, reward, done := step(state, action)
+			rewards = append(rewards, reward)
+			if done {
+				break
+			}
+			state = next
+			if action = m.Policy[state]; action == "" {
+				actions := m.actionsFor(state)
+				if len(actions) == 0 {
+					break
+				}
+				action = actions[rng.Intn(len(actions))]
+			}
+		}
+		if len(rewards) > 0 {
+			returns[ep] = RewardToGo(rewards, m.Discount)[0]
+		}
+	}
+
+	for _, g := range returns {
+		mean += g
+	}
+	mean /= float64(episodes)
+	if episodes == 1 {
+		return mean, 0
+	}
+	variance := 0.0
+	for _, g := range returns {
+		variance += (g - mean) * (g - mean)
+	}
+	variance /= float64(episodes - 1)
+	return mean, math.Sqrt(variance / float64(episodes))
+}
+
+// RewardToGo returns the discounted return from every step of a trajectory,
+// G_t = r_t + discount·G_{t+1}, computed backwards in one pass.
+func RewardToGo(rewards []float64, discount float64) []float64 {
+	togo := make([]float64, len(rewards))
+	g := 0.0
+	for t := len(rewards) - 1; t >= 0; t-- {
+		g = rewards[t] + discount*g
+		togo[t] = g
+	}
+	return togo
+}
@@ -0,0 +1,88 @@
+package mdplib
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// chainStep walks a -> b -> c deterministically, paying 1 per step and
+// ending on arrival at c.
+func chainStep(s State, a Action) (State, float64, bool) {
+	switch s {
+	case "a":
+		return "b", 1, false
+	case "b":
+		return "c", 1, true
+	}
+	return s, 0, true
+}
+
+func chainMDP() *MDP {
+	m := NewMDP([]State{"a", "b", "c"}, 0.5)
+	m.AddAction("a", "go", []Transition{{NextState: "b", Prob: 1, Reward: 1}})
+	m.AddAction("b", "go", []Transition{{NextState: "c", Prob: 1, Reward: 1}})
+	return m
+}
+
+func TestMonteCarloQConstantReturn(t *testing.T) {
+	m := chainMDP()
+	m.Policy["b"] = "go"
+	mean, stderr := m.MonteCarloQ("a", "go", 50, rand.New(rand.NewSource(1)), chainStep)
+	if !near(mean, 1.5, 1e-12) {
+		t.Errorf("mean = %v, want 1 + 0.5·1 = 1.5", mean)
+	}
+	if stderr != 0 {
+		t.Errorf("stderr = %v, want 0 for a constant return", stderr)
+	}
+}
+
+func TestMonteCarloQEmptyPolicyEntry(t *testing.T) {
+	m := chainMDP()
+	// ExtractPolicy leaves "" for states without actions; a blank entry for a
+	// state that does have actions must fall back to sampling one.
+	m.Policy["b"] = ""
+	var actions []Action
+	step := func(s State, a Action) (State, float64, bool) {
+		actions = append(actions, a)
+		return chainStep(s, a)
+	}
+	mean, _ := m.MonteCarloQ("a", "go", 1, rand.New(rand.NewSource(1)), step)
+	if len(actions) != 2 || actions[1] != "go" {
+		t.Errorf("actions taken = %q, want [go go]", actions)
+	}
+	if !near(mean, 1.5, 1e-12) {
+		t.Errorf("mean = %v, want 1.5", mean)
+	}
+
+	m.Policy["c"] = ""
+	calls := 0
+	never := func(s State, a Action) (State, float64, bool) {
+		calls++
+		return "c", 1, false
+	}
+	m.MonteCarloQ("b", "go", 1, rand.New(rand.NewSource(1)), never)
+	if calls != 1 {
+		t.Errorf("step called %d times, want the episode to stop at action-less c", calls)
+	}
+}
+
+func TestRewardToGo(t *testing.T) {
+	// 1 + 0.9·2 + 0.81·3, 2 + 0.9·3 and 3.
+	got := RewardToGo([]float64{1, 2, 3}, 0.9)
+	want := []float64{5.23, 4.7, 3}
+	if len(got) != len(want) {
+		t.Fatalf("RewardToGo = %v, want %v", got, want)
+	}
+	for i := range want {
+		if !near(got[i], want[i], 1e-12) {
+			t.Errorf("RewardToGo[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if got := RewardToGo([]float64{1, 2, 3}, 0); got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("discount 0: RewardToGo = %v, want the rewards themselves", got)
+	}
+	if got := RewardToGo(nil, 0.9); len(got) != 0 {
+		t.Errorf("empty trajectory: RewardToGo = %v", got)
+	}
+}
@@ -0,0 +1,300 @@
+package mdplib
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+func (m *MDP) ExtractPolicy() {
+	for s, a := range m.greedyPolicy(m.ValueFunc) {
+		m.Policy[s] = a
+	}
+}
+
+// greedyPolicy returns the action maximizing the one-step lookahead on values
+// in every state (the empty action for terminal states).
+func (m *MDP) greedyPolicy(values map[State]float64) map[State]Action {
+	return m.greedyPolicyModel(m, values)
+}
+
+func (m *MDP) greedyPolicyModel(model TransitionModel, values map[State]float64) map[State]Action {
+	policy := make(map[State]Action, len(m.States))
+	for _, s := range m.States {
+		bestAction := Action("")
+		bestValue := math.Inf(-1)
+		for _, a := range m.actionsFor(s) {
+			v := m.modelQValue(model, s, a, values)
+			if v > bestValue {
+				bestValue = v
+				bestAction = a
+			}
+		}
+		policy[s] = bestAction
+	}
+	return policy
+}
+
+func (m *MDP) PolicyIteration() {
+	// Initialize arbitrary policy
+	for _, s := range m.States {
+		if actions := m.actionsFor(s); len(actions) > 0 {
+			m.Policy[s] = actions[0]
+		}
+	}
+	m.improvePolicy()
+}
+
+// PolicyIterationFrom runs policy iteration starting from initial instead of
+// the first action of every state, and returns the number of improvement
+// steps taken (1 when initial is already optimal). States missing from
+// initial start with their first action; an action that isn't legal in its
+// state is an error and leaves Policy untouched.
+func (m *MDP) PolicyIterationFrom(initial map[State]Action) (int, error) {
+	start := make(map[State]Action, len(m.States))
+	for _, s := range m.States {
+		actions := m.actionsFor(s)
+		if len(actions) == 0 {
+			continue
+		}
+		a, ok := initial[s]
+		if !ok {
+			start[s] = actions[0]
+			continue
+		}
+		if !slices.Contains(actions, a) {
+			return 0, fmt.Errorf("PolicyIterationFrom: action %q is not legal in state %q", a, s)
+		}
+		start[s] = a
+	}
+
+	maps.Copy(m.Policy, start)
+	return m.improvePolicy(), nil
+}
+
+// improvePolicy alternates evaluation and greedy improvement from the current
+// Policy until it is stable, returning the number of improvement steps. A
+// state only switches action for a strictly better Q-value, so ties can't
+// make an optimal policy cycle.
+func (m *MDP) improvePolicy() int {
+	m.last = solveInfo{solver: SolverPolicyIteration}
+	for i := 0; i < m.MaxIterations; i++ {
+		m.policyEvaluation()
+		m.last.iterations++
+		policyStable := true
+
+		for _, s := range m.States {
+			oldAction := m.Policy[s]
+			bestAction := oldAction
+			bestValue := math.Inf(-1)
+			if slices.Contains(m.actionsFor(s), oldAction) {
+				bestValue = m.qValue(s, oldAction, m.ValueFunc)
+			}
+
+			for _, a := range m.actionsFor(s) {
+				v := m.qValue(s, a, m.ValueFunc)
+				if v > bestValue {
+					bestValue = v
+					bestAction = a
+				}
+			}
+
+			m.Policy[s] = bestAction
+			if bestAction != oldAction {
+				policyStable = false
+			}
+		}
+
+		if policyStable {
+			break
+		}
+	}
+	return m.last.iterations
+}
+
+func (m *MDP) policyEvaluation() {
+	m.ValueFunc = m.evaluatePolicy(m.Policy, m.ValueFunc)
+}
+
+// evaluatePolicy iteratively computes the value of policy starting from
+// initial, without touching ValueFunc or Policy.
+func (m *MDP) evaluatePolicy(policy map[State]Action, initial map[State]float64) map[State]float64 {
+	values := initial
+	for iter := 0; iter < m.MaxIterations; iter++ {
+		delta := 0.0
+		newValues := make(map[State]float64)
+
+		for _, s := range m.States {
+			v := m.qValue(s, policy[s], values)
+			newValues[s] = v
+			delta = math.Max(delta, math.Abs(v-values[s]))
+		}
+
+		values = newValues
+		if delta < m.Tolerance {
+			break
+		}
+	}
+	return values
+}
+
+// SolveAndEvaluate runs ValueIteration and ExtractPolicy, then evaluates the
+// greedy policy from scratch. It returns the optimal values and the greedy
+// policy's values, which should agree to within the tolerance at convergence;
+// a gap signals an under-converged or malformed MDP.
+func (m *MDP) SolveAndEvaluate() (optimal, greedy map[State]float64) {
+	m.ValueIteration()
+	m.ExtractPolicy()
+	return m.ValueFunc, m.evaluatePolicy(m.Policy, make(map[State]float64))
+}
+
+// OptimalActionSets returns, for every non-terminal state, the actions whose
+// Q-value under the current ValueFunc (which should be converged) is within
+// tolerance of the best. The product of the set sizes is the number of
+// distinct optimal deterministic policies.
+func (m *MDP) OptimalActionSets(tolerance float64) map[State][]Action {
+	sets := make(map[State][]Action, len(m.States))
+	for _, s := range m.States {
+		actions := m.actionsFor(s)
+		if len(actions) == 0 {
+			continue
+		}
+		q := make([]float64, len(actions))
+		best := math.Inf(-1)
+		for i, a := range actions {
+			q[i] = m.qValue(s, a, m.ValueFunc)
+			best = math.Max(best, q[i])
+		}
+		for i, a := range actions {
+			if best-q[i] <= tolerance {
+				sets[s] = append(sets[s], a)
+			}
+		}
+	}
+	return sets
+}
+
+// PolicyRegret returns Σ_s (V*(s) - V^π(s)), taking V* from the current
+// (converged) ValueFunc and evaluating policy from scratch.
+func (m *MDP) PolicyRegret(policy map[State]Action) float64 {
+	values := m.evaluatePolicy(policy, make(map[State]float64))
+	regret := 0.0
+	for _, s := range m.States {
+		regret += m.ValueFunc[s] - values[s]
+	}
+	return regret
+}
+
+// VerifyPolicyOptimal checks policy against the current ValueFunc (which
+// should be converged) and returns the states whose chosen action falls short
+// of the best Q-value by more than tolerance, or isn't a legal action at all.
+func (m *MDP) VerifyPolicyOptimal(policy map[State]Action, tolerance float64) (bool, []State) {
+	var suboptimal []State
+	for _, s := range m.States {
+		actions := m.actionsFor(s)
+		if len(actions) == 0 {
+			continue
+		}
+
+		bestValue := math.Inf(-1)
+		chosenValue := math.Inf(-1)
+		for _, a := range actions {
+			v := m.qValue(s, a, m.ValueFunc)
+			bestValue = math.Max(bestValue, v)
+			if a == policy[s] {
+				chosenValue = v
+			}
+		}
+		if bestValue-chosenValue > tolerance {
+			suboptimal = append(suboptimal, s)
+		}
+	}
+	return len(suboptimal) == 0, suboptimal
+}
+
+// OccupancyMeasure returns the discounted state visitation frequencies of
+// policy from startDist, the solution of
+// d(s) = startDist(s) + γ Σ_{s'} d(s') P(s|s', π(s')), found by fixed-point
+// iteration under Tolerance and MaxIterations. With no terminal states the
+// occupancies sum to 1/(1-γ); terminal states absorb mass without passing
+// it on.
+func (m *MDP) OccupancyMeasure(policy map[State]Action, startDist map[State]float64) map[State]float64 {
+	occupancy := make(map[State]float64, len(m.States))
+	for iter := 0; iter < m.MaxIterations; iter++ {
+		next := make(map[State]float64, len(m.States))
+		for _, s := range m.States {
+			next[s] += startDist[s]
+			for _, t := range m.Transitions[s][policy[s]] {
+				next[t.NextState] += m.Discount * occupancy[s] * t.Prob
+			}
+		}
+
+		delta := 0.0
+		for _, s := range m.States {
+			delta = math.Max(delta, math.Abs(next[s]-occupancy[s]))
+		}
+		occupancy = next
+		if delta < m.Tolerance {
+			break
+		}
+	}
+	return occupancy
+}
+
+// PolicyKL returns Σ_s w(s)·KL(p(·|s) || q(·|s)) for stochastic policies given
+// as per-state action distributions. Actions with p = 0 contribute nothing
+// and an action with p > 0 but q = 0 makes the divergence +Inf. Only states
+// with a positive weight count; a nil stateWeights weighs every state of p
+// by 1.
+func PolicyKL(p, q map[State]map[Action]float64, stateWeights map[State]float64) float64 {
+	kl := 0.0
+	for s, ps := range p {
+		w := 1.0
+		if stateWeights != nil {
+			w = stateWeights[s]
+		}
+		if w <= 0 {
+			continue
+		}
+		for a, pa := range ps {
+			if pa <= 0 {
+				continue
+			}
+			qa := q[s][a]
+			if qa <= 0 {
+				return math.Inf(1)
+			}
+			kl += w * pa * math.Log(pa/qa)
+		}
+	}
+	return kl
+}
+
+// PolicyAsRules summarizes Policy by grouping states under groupBy(s): every
+// group whose states all share one action becomes a single rule, and the
+// groups with mixed actions are returned sorted instead. States without a
+// policy action (e.g. terminal states) are ignored.
+func (m *MDP) PolicyAsRules(groupBy func(State) string) (rules map[string]Action, mixed []string) {
+	rules = make(map[string]Action)
+	isMixed := make(map[string]bool)
+	for _, s := range m.States {
+		a := m.Policy[s]
+		if a == "" {
+			continue
+		}
+		key := groupBy(s)
+		if isMixed[key] {
+			continue
+		}
+		if prev, ok := rules[key]; ok && prev != a {
+			delete(rules, key)
+			isMixed[key] = true
+			mixed = append(mixed, key)
+			continue
+		}
+		rules[key] = a
+	}
+	slices.Sort(mixed)
+	return rules, mixed
+}
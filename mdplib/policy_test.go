@@ -0,0 +1,216 @@
+package mdplib
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVerifyPolicyOptimal(t *testing.T) {
+	m := NewGridWorld(3, 3, nil, [2]int{2, 2}, -1, 10, 0)
+	m.ValueIteration()
+	m.ExtractPolicy()
+	if ok, bad := m.VerifyPolicyOptimal(m.Policy, 1e-6); !ok {
+		t.Fatalf("extracted policy flagged at %v", bad)
+	}
+
+	policy := make(map[State]Action, len(m.Policy))
+	for s, a := range m.Policy {
+		policy[s] = a
+	}
+	policy[GridState(1, 2)] = Up
+	ok, bad := m.VerifyPolicyOptimal(policy, 1e-6)
+	if ok || !slices.Equal(bad, []State{GridState(1, 2)}) {
+		t.Errorf("VerifyPolicyOptimal = %v, %v, want false, [1,2]", ok, bad)
+	}
+
+	policy[GridState(1, 2)] = "teleport"
+	if _, bad := m.VerifyPolicyOptimal(policy, 1e-6); !slices.Equal(bad, []State{GridState(1, 2)}) {
+		t.Errorf("unknown action: suboptimal = %v, want [1,2]", bad)
+	}
+}
+
+func TestPolicyRegret(t *testing.T) {
+	m := choiceMDP()
+	m.ValueIteration()
+	m.ExtractPolicy()
+	if r := m.PolicyRegret(m.Policy); !near(r, 0, 1e-9) {
+		t.Errorf("optimal policy regret = %v, want 0", r)
+	}
+	// "bad" earns 1 instead of 10 from start; end is terminal either way.
+	if r := m.PolicyRegret(map[State]Action{"start": "bad"}); !near(r, 9, 1e-9) {
+		t.Errorf("bad policy regret = %v, want 9", r)
+	}
+}
+
+func TestPolicyIterationFrom(t *testing.T) {
+	m := NewGridWorld(4, 4, [][2]int{{1, 1}}, [2]int{3, 3}, -1, 10, 0.1)
+	m.ValueIteration()
+	m.ExtractPolicy()
+	optimal := maps.Clone(m.Policy)
+
+	warm := NewGridWorld(4, 4, [][2]int{{1, 1}}, [2]int{3, 3}, -1, 10, 0.1)
+	steps, err := warm.PolicyIterationFrom(optimal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps != 1 {
+		t.Errorf("warm start took %d improvement steps, want 1", steps)
+	}
+	for _, s := range warm.States {
+		if warm.Policy[s] != optimal[s] {
+			t.Errorf("Policy[%s] = %q, want %q", s, warm.Policy[s], optimal[s])
+		}
+	}
+
+	cold := NewGridWorld(4, 4, [][2]int{{1, 1}}, [2]int{3, 3}, -1, 10, 0.1)
+	if coldSteps, _ := cold.PolicyIterationFrom(nil); coldSteps <= 1 {
+		t.Errorf("cold start took %d steps; the test needs a non-optimal first-action policy", coldSteps)
+	}
+
+	bad := maps.Clone(optimal)
+	bad[GridState(0, 0)] = "teleport"
+	before := maps.Clone(warm.Policy)
+	if _, err := warm.PolicyIterationFrom(bad); err == nil {
+		t.Error("an illegal initial action was accepted")
+	}
+	if !maps.Equal(warm.Policy, before) {
+		t.Error("a rejected initial policy changed Policy")
+	}
+}
+
+func TestOccupancyMeasure(t *testing.T) {
+	// A recurrent three-state cycle: no terminal state absorbs mass.
+	m := NewMDP([]State{"a", "b", "c"}, 0.8)
+	m.Tolerance = 1e-12
+	m.MaxIterations = 10000
+	m.AddAction("a", "next", []Transition{{NextState: "b", Prob: 1}})
+	m.AddAction("b", "next", []Transition{{NextState: "c", Prob: 0.5}, {NextState: "a", Prob: 0.5}})
+	m.AddAction("c", "next", []Transition{{NextState: "a", Prob: 1}})
+	policy := map[State]Action{"a": "next", "b": "next", "c": "next"}
+
+	d := m.OccupancyMeasure(policy, map[State]float64{"a": 1})
+	total := d["a"] + d["b"] + d["c"]
+	if !near(total, 1/(1-0.8), 1e-8) {
+		t.Errorf("occupancies sum to %v, want 1/(1-γ) = 5", total)
+	}
+	// d(b) = γ·d(a) and d(c) = γ·0.5·d(b).
+	if !near(d["b"], 0.8*d["a"], 1e-8) || !near(d["c"], 0.4*d["b"], 1e-8) {
+		t.Errorf("occupancies = %v, violate the flow equations", d)
+	}
+
+	// A terminal state absorbs mass without passing it on.
+	chain := forkMDP()
+	chain.Tolerance = 1e-12
+	d = chain.OccupancyMeasure(map[State]Action{"a": "go", "b": "go"}, map[State]float64{"a": 1})
+	if !near(d["a"], 1, 1e-12) || !near(d["b"], 0.45, 1e-12) || !near(d["c"], 0.45+0.9*0.45, 1e-12) {
+		t.Errorf("chain occupancies = %v, want a 1, b 0.45, c 0.855", d)
+	}
+}
+
+func TestPolicyKL(t *testing.T) {
+	p := map[State]map[Action]float64{
+		"s": {"a": 0.5, "b": 0.5},
+		"t": {"a": 1, "b": 0},
+	}
+	if kl := PolicyKL(p, p, nil); kl != 0 {
+		t.Errorf("KL(p || p) = %v, want 0", kl)
+	}
+
+	q := map[State]map[Action]float64{
+		"s": {"a": 0.25, "b": 0.75},
+		"t": {"a": 0.5, "b": 0.5},
+	}
+	// KL_s = 0.5·ln(0.5/0.25) + 0.5·ln(0.5/0.75) = 0.5·ln(4/3),
+	// KL_t = ln 2 (the b term has p = 0).
+	klS, klT := 0.5*math.Log(4.0/3), math.Ln2
+	if kl := PolicyKL(p, q, nil); !near(kl, klS+klT, 1e-12) {
+		t.Errorf("unweighted KL = %v, want %v", kl, klS+klT)
+	}
+	weights := map[State]float64{"s": 2, "t": 0}
+	if kl := PolicyKL(p, q, weights); !near(kl, 2*klS, 1e-12) {
+		t.Errorf("weighted KL = %v, want %v", kl, 2*klS)
+	}
+	if kl := PolicyKL(q, p, nil); !math.IsInf(kl, 1) {
+		t.Errorf("KL with q = 0 where p > 0 = %v, want +Inf", kl)
+	}
+}
+
+func TestSolveAndEvaluate(t *testing.T) {
+	m := NewGridWorld(4, 4, [][2]int{{1, 1}}, [2]int{3, 3}, -1, 10, 0.2)
+	m.Tolerance = 1e-9
+	optimal, greedy := m.SolveAndEvaluate()
+	for _, s := range m.States {
+		if !near(optimal[s], greedy[s], 1e-6) {
+			t.Errorf("%s: optimal %v, greedy policy %v", s, optimal[s], greedy[s])
+		}
+	}
+	if m.Policy[GridState(2, 3)] != Down {
+		t.Errorf("Policy[2,3] = %q, want down into the goal", m.Policy[GridState(2, 3)])
+	}
+}
+
+func TestOptimalActionSets(t *testing.T) {
+	// From the corner of a symmetric grid, heading right or down to the
+	// opposite corner are equally good.
+	m := NewGridWorld(3, 3, nil, [2]int{2, 2}, -1, 10, 0)
+	m.Tolerance = 1e-12
+	m.ValueIteration()
+	sets := m.OptimalActionSets(1e-9)
+
+	if got := sets[GridState(0, 0)]; !slices.Equal(slices.Sorted(slices.Values(got)), []Action{Down, Right}) {
+		t.Errorf("corner actions = %v, want down and right", got)
+	}
+	if got := sets[GridState(1, 2)]; !slices.Equal(got, []Action{Down}) {
+		t.Errorf("actions next to the goal = %v, want only down", got)
+	}
+	if _, ok := sets[GridState(2, 2)]; ok {
+		t.Error("the terminal goal has an action set")
+	}
+
+	count := 1
+	for _, actions := range sets {
+		count *= len(actions)
+	}
+	// Each of 0,0, 0,1, 1,0 and 1,1 has two equally short moves.
+	if count != 16 {
+		t.Errorf("%d distinct optimal policies, want 16", count)
+	}
+}
+
+func TestPolicyAsRulesGroupsByPrefix(t *testing.T) {
+	// Low-battery states should charge and high-battery ones work; the two
+	// "mid" states disagree.
+	prefer := map[State]Action{
+		"low/0": "charge", "low/1": "charge", "low/2": "charge",
+		"high/0": "work", "high/1": "work", "high/2": "work",
+		"mid/0": "charge", "mid/1": "work",
+	}
+	m := NewMDP([]State{"done"}, 0.9)
+	for _, s := range slices.Sorted(maps.Keys(prefer)) {
+		m.States = append(m.States, s)
+		for _, a := range []Action{"charge", "work"} {
+			reward := 1.0
+			if a == prefer[s] {
+				reward = 5
+			}
+			m.AddAction(s, a, []Transition{{NextState: "done", Prob: 1, Reward: reward}})
+		}
+	}
+	m.ValueIteration()
+	m.ExtractPolicy()
+
+	prefix := func(s State) string {
+		p, _, _ := strings.Cut(string(s), "/")
+		return p
+	}
+	rules, mixed := m.PolicyAsRules(prefix)
+	if want := map[string]Action{"low": "charge", "high": "work"}; !maps.Equal(rules, want) {
+		t.Errorf("rules = %v, want %v", rules, want)
+	}
+	if !slices.Equal(mixed, []string{"mid"}) {
+		t.Errorf("mixed groups = %v, want [mid]", mixed)
+	}
+}
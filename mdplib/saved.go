@@ -0,0 +1,150 @@
+package mdplib
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"strings"
+)
+
+// SavedMDPVersion is the SavedMDP format written by SaveJSON. Version 2 added
+// RewardClip; LoadMDPJSON reads versions 1 and 2 and rejects any other.
+const SavedMDPVersion = 2
+
+// Values of SavedMDP.Solver
+const (
+	SolverValueIteration  = "value_iteration"
+	SolverPolicyIteration = "policy_iteration"
+)
+
+// SavedMDP is the on-disk form of an MDP and its solution, with enough
+// metadata to reproduce the solve.
+type SavedMDP struct {
+	Version       int                `json:"version"`
+	Solver        string             `json:"solver,omitempty"` // empty if never solved
+	Discount      float64            `json:"discount"`
+	Tolerance     float64            `json:"tolerance"`
+	MaxIterations int                `json:"max_iterations"`
+	Iterations    int                `json:"iterations"` // sweeps or improvement steps of the last solve
+	States        []State            `json:"states"`
+	Transitions   []RawTransition    `json:"transitions"`
+	LegalActions  map[State][]Action `json:"legal_actions,omitempty"`
+	RewardClip    [2]float64         `json:"reward_clip"`
+	ValueFunc     map[State]float64  `json:"values,omitempty"`
+	Policy        map[State]Action   `json:"policy,omitempty"`
+}
+
+// SaveJSON writes the MDP, its ValueFunc and Policy, and the metadata of the
+// last solve as a SavedMDP, gzip-compressed if path ends in .gz.
+func (m *MDP) SaveJSON(path string) error {
+	saved := SavedMDP{
+		Version:       SavedMDPVersion,
+		Solver:        m.last.solver,
+		Discount:      m.Discount,
+		Tolerance:     m.Tolerance,
+		MaxIterations: m.MaxIterations,
+		Iterations:    m.last.iterations,
+		States:        m.States,
+		LegalActions:  m.LegalActions,
+		RewardClip:    m.RewardClip,
+		ValueFunc:     m.ValueFunc,
+		Policy:        m.Policy,
+	}
+	for _, s := range m.States {
+		for _, a := range m.Actions[s] {
+			for _, t := range m.Transitions[s][a] {
+				saved.Transitions = append(saved.Transitions, RawTransition{
+					State:     string(s),
+					Action:    string(a),
+					NextState: string(t.NextState),
+					Prob:      t.Prob,
+					Reward:    t.Reward,
+					Rewards:   t.Rewards,
+				})
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// LoadMDPJSON reads a file written by SaveJSON, refusing versions it doesn't
+// understand. Use SavedMDP.MDP to rebuild the MDP.
+func LoadMDPJSON(path string) (*SavedMDP, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved SavedMDP
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	if saved.Version < 1 || saved.Version > SavedMDPVersion {
+		return nil, fmt.Errorf("LoadMDPJSON: %s: %w %d, this build reads versions 1 to %d", path, ErrUnsupportedVersion, saved.Version, SavedMDPVersion)
+	}
+	return &saved, nil
+}
+
+// MDP rebuilds the saved MDP with its values, policy and solve metadata.
+func (sm *SavedMDP) MDP() *MDP {
+	m := NewMDP(append([]State(nil), sm.States...), sm.Discount)
+	m.Tolerance = sm.Tolerance
+	m.MaxIterations = sm.MaxIterations
+	m.RewardClip = sm.RewardClip
+	m.last = solveInfo{solver: sm.Solver, iterations: sm.Iterations, residual: math.Inf(1)}
+	for _, raw := range sm.Transitions {
+		s, a := State(raw.State), Action(raw.Action)
+		if m.Transitions[s] == nil {
+			m.Transitions[s] = make(map[Action][]Transition)
+		}
+		m.Actions[s] = appendIfMissingAction(m.Actions[s], a)
+		m.Transitions[s][a] = append(m.Transitions[s][a], Transition{
+			NextState: State(raw.NextState), Prob: raw.Prob, Reward: raw.Reward, Rewards: raw.Rewards,
+		})
+	}
+	if sm.LegalActions != nil {
+		m.LegalActions = make(map[State][]Action, len(sm.LegalActions))
+		for s, legal := range sm.LegalActions {
+			m.LegalActions[s] = append([]Action(nil), legal...)
+		}
+	}
+	maps.Copy(m.ValueFunc, sm.ValueFunc)
+	maps.Copy(m.Policy, sm.Policy)
+	return m
+}
+
+// writeFile writes data to path, gzip-compressing it for .gz names.
+func writeFile(path string, data []byte) error {
+	if !strings.HasSuffix(path, ".gz") {
+		return os.WriteFile(path, data, 0644)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,77 @@
+package mdplib
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveJSONRoundTrip(t *testing.T) {
+	m := choiceMDP()
+	m.Transitions["start"]["best"][0].Rewards = []float64{4, 6}
+	m.RewardClip = [2]float64{0, 4}
+	if err := m.SetLegalActions("start", []Action{"ok", "bad"}); err != nil {
+		t.Fatal(err)
+	}
+	m.ValueIteration()
+	m.ExtractPolicy()
+
+	for _, name := range []string{"mdp.json", "mdp.json.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := m.SaveJSON(path); err != nil {
+			t.Fatal(err)
+		}
+		saved, err := LoadMDPJSON(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Version != SavedMDPVersion || saved.Solver != SolverValueIteration || saved.Iterations != m.last.iterations {
+			t.Errorf("%s: metadata = version %d solver %q iterations %d", name, saved.Version, saved.Solver, saved.Iterations)
+		}
+
+		got := saved.MDP()
+		if !reflect.DeepEqual(got.States, m.States) || !reflect.DeepEqual(got.Actions, m.Actions) ||
+			!reflect.DeepEqual(got.Transitions, m.Transitions) {
+			t.Errorf("%s: transitions changed in the round trip", name)
+		}
+		if !reflect.DeepEqual(got.LegalActions, m.LegalActions) || got.RewardClip != m.RewardClip {
+			t.Errorf("%s: LegalActions %v, RewardClip %v, want %v, %v", name, got.LegalActions, got.RewardClip, m.LegalActions, m.RewardClip)
+		}
+		if !maps.Equal(got.ValueFunc, m.ValueFunc) || !maps.Equal(got.Policy, m.Policy) {
+			t.Errorf("%s: values %v, policy %v, want %v, %v", name, got.ValueFunc, got.Policy, m.ValueFunc, m.Policy)
+		}
+
+		// Re-solving the loaded MDP must honor the mask and clip.
+		got.ValueIteration()
+		if !near(got.ValueFunc["start"], 4, 1e-9) {
+			t.Errorf("%s: re-solved V(start) = %v, want the masked and clipped 4", name, got.ValueFunc["start"])
+		}
+	}
+}
+
+func TestLoadMDPJSONRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdp.json")
+	os.WriteFile(path, []byte(`{"version": 99, "discount": 0.9, "states": ["a"], "transitions": []}`), 0644)
+	_, err := LoadMDPJSON(path)
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("err = %v, want ErrUnsupportedVersion", err)
+	}
+	if !strings.Contains(err.Error(), "99") || !strings.Contains(err.Error(), path) {
+		t.Errorf("error %q should name the file and its version", err)
+	}
+
+	// Version 1 files, written before RewardClip, still load.
+	os.WriteFile(path, []byte(`{"version": 1, "discount": 0.9, "states": ["a", "b"],
+		"transitions": [{"state": "a", "action": "go", "next_state": "b", "prob": 1, "reward": 2}]}`), 0644)
+	saved, err := LoadMDPJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := saved.MDP(); m.Transitions["a"]["go"][0].Reward != 2 || m.RewardClip != [2]float64{} {
+		t.Errorf("version 1 file loaded as %+v, clip %v", m.Transitions, m.RewardClip)
+	}
+}
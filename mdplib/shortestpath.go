@@ -0,0 +1,74 @@
+package mdplib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ShortestPathValues computes optimal values directly for shortest-path MDPs:
+// deterministic transitions, the same negative reward c on every step, and
+// goal as the only terminal state. A breadth-first search backward from goal
+// gives each state's distance d, and V(s) = c(1-γ^d)/(1-γ) (c·d when γ = 1).
+// States that cannot reach goal get the value of never arriving. MDPs that
+// don't fit this shape return an error; use ValueIteration for those.
+func (m *MDP) ShortestPathValues(goal State) (map[State]float64, error) {
+	if !m.IsDeterministic() {
+		return nil, errors.New("ShortestPathValues: MDP is stochastic, use ValueIteration")
+	}
+
+	stepReward := 0.0
+	predecessors := make(map[State][]State)
+	for _, s := range m.States {
+		actions := m.actionsFor(s)
+		if s == goal {
+			if len(actions) > 0 {
+				return nil, fmt.Errorf("ShortestPathValues: goal %q must be terminal (no actions)", goal)
+			}
+			continue
+		}
+		if len(actions) == 0 {
+			return nil, fmt.Errorf("ShortestPathValues: %q is a terminal state other than the goal, use ValueIteration", s)
+		}
+		for _, a := range actions {
+			t := m.Transitions[s][a][0]
+			r := m.reward(t)
+			if stepReward == 0 {
+				stepReward = r
+			}
+			if r >= 0 || r != stepReward {
+				return nil, fmt.Errorf("ShortestPathValues: rewards must all equal the same negative step cost, got %v and %v; use ValueIteration", stepReward, r)
+			}
+			predecessors[t.NextState] = append(predecessors[t.NextState], s)
+		}
+	}
+
+	dist := map[State]int{goal: 0}
+	queue := []State{goal}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, p := range predecessors[s] {
+			if _, seen := dist[p]; !seen {
+				dist[p] = dist[s] + 1
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	values := make(map[State]float64, len(m.States))
+	for _, s := range m.States {
+		d, ok := dist[s]
+		switch {
+		case m.Discount == 1 && ok:
+			values[s] = stepReward * float64(d)
+		case m.Discount == 1:
+			values[s] = math.Inf(-1)
+		case ok:
+			values[s] = stepReward * (1 - math.Pow(m.Discount, float64(d))) / (1 - m.Discount)
+		default:
+			values[s] = stepReward / (1 - m.Discount)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,78 @@
+package mdplib
+
+import "testing"
+
+// shortestPathGrid is a slip-free gridworld where every step, including the
+// one into the goal, costs 1.
+func shortestPathGrid() *MDP {
+	m := gridMDP(12, [][2]int{{3, 3}, {3, 4}, {7, 8}}, map[[2]int]float64{{11, 11}: -1}, 0)
+	m.Discount = 0.9
+	m.Tolerance = 1e-9
+	return m
+}
+
+func TestShortestPathValuesMatchesValueIteration(t *testing.T) {
+	m := shortestPathGrid()
+	got, err := m.ShortestPathValues("11,11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.ValueIteration()
+	for _, s := range m.States {
+		if !near(got[s], m.ValueFunc[s], 1e-6) {
+			t.Errorf("V(%s) = %v, ValueIteration gives %v", s, got[s], m.ValueFunc[s])
+		}
+	}
+	// (0,0) is 22 steps from the goal: V = -(1-0.9^22)/(1-0.9).
+	if !near(got["0,0"], -9.015229097816, 1e-9) {
+		t.Errorf("V(0,0) = %v", got["0,0"])
+	}
+}
+
+func TestShortestPathValuesHonorsRewardClip(t *testing.T) {
+	// The goal step costs 5, so only the clip to [-1, 0] makes the costs uniform.
+	m := gridMDP(12, [][2]int{{3, 3}, {3, 4}, {7, 8}}, map[[2]int]float64{{11, 11}: -5}, 0)
+	m.Discount = 0.9
+	m.Tolerance = 1e-9
+	if _, err := m.ShortestPathValues("11,11"); err == nil {
+		t.Fatal("unclipped MDP with a costlier goal step was accepted")
+	}
+	m.RewardClip = [2]float64{-1, 0}
+	got, err := m.ShortestPathValues("11,11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.ValueIteration()
+	for _, s := range m.States {
+		if !near(got[s], m.ValueFunc[s], 1e-6) {
+			t.Errorf("V(%s) = %v, clipped ValueIteration gives %v", s, got[s], m.ValueFunc[s])
+		}
+	}
+}
+
+func TestShortestPathValuesRejectsOtherMDPs(t *testing.T) {
+	goal := State("2,2")
+	for name, m := range map[string]*MDP{
+		"stochastic":      gridMDP(3, nil, map[[2]int]float64{{2, 2}: -1}, 0.2),
+		"positive reward": gridMDP(3, nil, map[[2]int]float64{{2, 2}: 10}, 0),
+	} {
+		if _, err := m.ShortestPathValues(goal); err == nil {
+			t.Errorf("%s: ShortestPathValues returned no error", name)
+		}
+	}
+}
+
+func BenchmarkShortestPathValues(b *testing.B) {
+	m := shortestPathGrid()
+	for i := 0; i < b.N; i++ {
+		m.ShortestPathValues("11,11")
+	}
+}
+
+func BenchmarkShortestPathValueIteration(b *testing.B) {
+	m := shortestPathGrid()
+	for i := 0; i < b.N; i++ {
+		m.ValueFunc = make(map[State]float64)
+		m.ValueIteration()
+	}
+}
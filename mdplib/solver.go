@@ -0,0 +1,164 @@
+package mdplib
+
+import (
+	"math"
+	"sync"
+)
+
+// Solver holds the transition structure of an MDP flattened into index
+// arrays so it can be solved repeatedly (e.g. over a sweep of discounts)
+// without walking the nested maps every sweep. It is a snapshot: changes to
+// the MDP after NewSolver are not seen.
+type Solver struct {
+	Tolerance     float64
+	MaxIterations int
+	Workers       int // sweeps are split across this many goroutines when > 1
+
+	states      []State
+	actions     []Action // all actions, grouped by state
+	actionStart []int    // actions of state i are actions[actionStart[i]:actionStart[i+1]]
+	transStart  []int    // transitions of action k are next[transStart[k]:transStart[k+1]]
+	next        []int    // successor state index, -1 for states outside States
+	prob        []float64
+	reward      []float64
+}
+
+func NewSolver(m *MDP) *Solver {
+	sv := &Solver{
+		Tolerance:     m.Tolerance,
+		MaxIterations: m.MaxIterations,
+		states:        append([]State(nil), m.States...),
+	}
+
+	index := make(map[State]int, len(m.States))
+	for i, s := range m.States {
+		index[s] = i
+	}
+	for _, s := range m.States {
+		sv.actionStart = append(sv.actionStart, len(sv.actions))
+		for _, a := range m.actionsFor(s) {
+			sv.actions = append(sv.actions, a)
+			sv.transStart = append(sv.transStart, len(sv.next))
+			for _, t := range m.Transitions[s][a] {
+				next, ok := index[t.NextState]
+				if !ok {
+					next = -1
+				}
+				sv.next = append(sv.next, next)
+				sv.prob = append(sv.prob, t.Prob)
+				sv.reward = append(sv.reward, m.reward(t))
+			}
+		}
+	}
+	sv.actionStart = append(sv.actionStart, len(sv.actions))
+	sv.transStart = append(sv.transStart, len(sv.next))
+	return sv
+}
+
+// CompiledMDP is a frozen, array-backed copy of an MDP's transition
+// structure for solving it many times with different discounts and
+// tolerances.
+type CompiledMDP struct {
+	solver *Solver
+}
+
+// Compile flattens the MDP's states, available actions and transitions into a
+// CompiledMDP. Like NewSolver, later changes to the MDP are not seen.
+func (m *MDP) Compile() *CompiledMDP {
+	return &CompiledMDP{solver: NewSolver(m)}
+}
+
+// Solve runs value iteration at the given discount until the largest change
+// drops below tolerance (or the MDP's MaxIterations at compile time is
+// reached) and returns the optimal values and greedy policy.
+func (c *CompiledMDP) Solve(discount, tolerance float64) (map[State]float64, map[State]Action) {
+	sv := *c.solver
+	sv.Tolerance = tolerance
+	return sv.Solve(discount)
+}
+
+// Solve runs value iteration at the given discount and returns the optimal
+// values and greedy policy.
+func (sv *Solver) Solve(discount float64) (map[State]float64, map[State]Action) {
+	values := make([]float64, len(sv.states))
+	newValues := make([]float64, len(sv.states))
+	best := make([]int, len(sv.states))
+
+	for iter := 0; iter < sv.MaxIterations; iter++ {
+		delta := sv.sweep(discount, values, newValues, best)
+		values, newValues = newValues, values
+		if delta < sv.Tolerance {
+			break
+		}
+	}
+	// One more greedy pass so the policy matches the final values.
+	sv.sweep(discount, values, newValues, best)
+
+	valueMap := make(map[State]float64, len(sv.states))
+	policy := make(map[State]Action, len(sv.states))
+	for i, s := range sv.states {
+		valueMap[s] = values[i]
+		if best[i] >= 0 {
+			policy[s] = sv.actions[best[i]]
+		}
+	}
+	return valueMap, policy
+}
+
+func (sv *Solver) sweep(discount float64, values, newValues []float64, best []int) float64 {
+	workers := sv.Workers
+	if workers <= 1 || len(sv.states) < workers {
+		return sv.sweepRange(discount, values, newValues, best, 0, len(sv.states))
+	}
+
+	deltas := make([]float64, workers)
+	chunk := (len(sv.states) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		lo := w * chunk
+		hi := min(lo+chunk, len(sv.states))
+		if lo >= hi {
+			continue
+		}
+		wg.Add(1)
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			deltas[w] = sv.sweepRange(discount, values, newValues, best, lo, hi)
+		}(w, lo, hi)
+	}
+	wg.Wait()
+
+	delta := 0.0
+	for _, d := range deltas {
+		delta = math.Max(delta, d)
+	}
+	return delta
+}
+
+func (sv *Solver) sweepRange(discount float64, values, newValues []float64, best []int, lo, hi int) float64 {
+	delta := 0.0
+	for i := lo; i < hi; i++ {
+		bestValue := math.Inf(-1)
+		best[i] = -1
+		for k := sv.actionStart[i]; k < sv.actionStart[i+1]; k++ {
+			v := 0.0
+			for t := sv.transStart[k]; t < sv.transStart[k+1]; t++ {
+				next := 0.0
+				if sv.next[t] >= 0 {
+					next = values[sv.next[t]]
+				}
+				v += sv.prob[t] * (sv.reward[t] + discount*next)
+			}
+			if v > bestValue {
+				bestValue = v
+				best[i] = k
+			}
+		}
+		if best[i] < 0 {
+			bestValue = 0 // terminal
+		}
+		newValues[i] = bestValue
+		delta = math.Max(delta, math.Abs(bestValue-values[i]))
+	}
+	return delta
+}
@@ -0,0 +1,109 @@
+package mdplib
+
+import "testing"
+
+func TestSolverMatchesValueIteration(t *testing.T) {
+	m := gridMDP(6, [][2]int{{2, 2}}, map[[2]int]float64{{5, 5}: 10}, 0.2)
+	m.Tolerance = 1e-10
+	sv := NewSolver(m)
+
+	for _, discount := range []float64{0.5, 0.9, 0.95} {
+		m.Discount = discount
+		m.ValueIteration()
+		m.ExtractPolicy()
+		for _, workers := range []int{1, 4} {
+			sv.Workers = workers
+			values, policy := sv.Solve(discount)
+			for _, s := range m.States {
+				if !near(values[s], m.ValueFunc[s], 1e-8) {
+					t.Errorf("γ=%v workers=%d: V(%s) = %v, want %v", discount, workers, s, values[s], m.ValueFunc[s])
+				}
+				if policy[s] != m.Policy[s] {
+					t.Errorf("γ=%v workers=%d: π(%s) = %q, want %q", discount, workers, s, policy[s], m.Policy[s])
+				}
+			}
+		}
+	}
+}
+
+var benchDiscounts = []float64{0.5, 0.55, 0.6, 0.65, 0.7, 0.75, 0.8, 0.85, 0.9, 0.95}
+
+func benchGrid() *MDP {
+	m := gridMDP(15, nil, map[[2]int]float64{{14, 14}: 10}, 0.2)
+	m.Tolerance = 1e-6
+	return m
+}
+
+func BenchmarkSolverDiscountSweep(b *testing.B) {
+	m := benchGrid()
+	for i := 0; i < b.N; i++ {
+		sv := NewSolver(m)
+		for _, d := range benchDiscounts {
+			sv.Solve(d)
+		}
+	}
+}
+
+func BenchmarkValueIterationDiscountSweep(b *testing.B) {
+	m := benchGrid()
+	for i := 0; i < b.N; i++ {
+		for _, d := range benchDiscounts {
+			m.Discount = d
+			m.ValueFunc = make(map[State]float64)
+			m.ValueIteration()
+		}
+	}
+}
+
+func TestCompiledMDP(t *testing.T) {
+	m := NewGridWorld(5, 5, [][2]int{{1, 3}}, [2]int{4, 4}, -1, 10, 0.1)
+	m.Tolerance = 1e-10
+	compiled := m.Compile()
+	// Compile is a snapshot: a later change to the MDP must not leak in.
+	m.Transitions[GridState(3, 4)][Down][0].Reward = 1000
+
+	reference := NewGridWorld(5, 5, [][2]int{{1, 3}}, [2]int{4, 4}, -1, 10, 0.1)
+	reference.Tolerance = 1e-10
+	for _, discount := range compileDiscounts {
+		values, policy := compiled.Solve(discount, 1e-10)
+		reference.Discount = discount
+		reference.ValueIteration()
+		reference.ExtractPolicy()
+		for _, s := range reference.States {
+			if !near(values[s], reference.ValueFunc[s], 1e-8) || policy[s] != reference.Policy[s] {
+				t.Errorf("γ=%v: %s has value %v and action %q, want %v and %q",
+					discount, s, values[s], policy[s], reference.ValueFunc[s], reference.Policy[s])
+			}
+		}
+	}
+}
+
+// compileDiscounts is the 20-discount sweep Compile is meant for.
+var compileDiscounts = func() []float64 {
+	ds := make([]float64, 20)
+	for i := range ds {
+		ds[i] = 0.5 + 0.025*float64(i)
+	}
+	return ds
+}()
+
+func BenchmarkCompiledSolve20Discounts(b *testing.B) {
+	m := benchGrid()
+	for i := 0; i < b.N; i++ {
+		compiled := m.Compile()
+		for _, d := range compileDiscounts {
+			compiled.Solve(d, m.Tolerance)
+		}
+	}
+}
+
+func BenchmarkValueIteration20Discounts(b *testing.B) {
+	m := benchGrid()
+	for i := 0; i < b.N; i++ {
+		for _, d := range compileDiscounts {
+			m.Discount = d
+			m.ValueFunc = make(map[State]float64)
+			m.ValueIteration()
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package mdplib
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ExitState is the synthetic terminal state SubMDP routes departing
+// transitions to.
+const ExitState State = "EXIT"
+
+// SubMDP returns a new MDP containing only the given states and the
+// transitions among them. For each action, the transitions that leave the set
+// are merged into a single transition to ExitState carrying their total
+// probability and their probability-weighted mean reward, so expected
+// one-step rewards are preserved. ExitState has no actions and is terminal.
+func (m *MDP) SubMDP(states []State) *MDP {
+	keep := make(map[State]bool, len(states))
+	var kept []State
+	for _, s := range states {
+		if !keep[s] {
+			keep[s] = true
+			kept = append(kept, s)
+		}
+	}
+
+	sub := NewMDP(kept, m.Discount)
+	sub.Tolerance = m.Tolerance
+	sub.MaxIterations = m.MaxIterations
+
+	hasExit := false
+	for _, s := range kept {
+		for _, a := range m.Actions[s] {
+			var ts []Transition
+			exitProb, exitReward := 0.0, 0.0
+			for _, t := range m.Transitions[s][a] {
+				if keep[t.NextState] {
+					ts = append(ts, t)
+					continue
+				}
+				exitProb += t.Prob
+				exitReward += t.Prob * t.Reward
+			}
+			if exitProb > 0 {
+				ts = append(ts, Transition{NextState: ExitState, Prob: exitProb, Reward: exitReward / exitProb})
+				hasExit = true
+			}
+			sub.AddAction(s, a, ts)
+		}
+		if legal, ok := m.LegalActions[s]; ok {
+			if sub.LegalActions == nil {
+				sub.LegalActions = make(map[State][]Action)
+			}
+			sub.LegalActions[s] = append([]Action(nil), legal...)
+		}
+	}
+	if hasExit && !keep[ExitState] {
+		sub.States = append(sub.States, ExitState)
+	}
+	return sub
+}
+
+// UsedActions returns, for every state with a Policy entry that is one of its
+// available actions, that action.
+func (m *MDP) UsedActions() map[State][]Action {
+	used := make(map[State][]Action, len(m.States))
+	for _, s := range m.States {
+		a, ok := m.Policy[s]
+		if !ok {
+			continue
+		}
+		if slices.Contains(m.actionsFor(s), a) {
+			used[s] = []Action{a}
+		}
+	}
+	return used
+}
+
+// PruneUnusedActions returns a new MDP keeping only UsedActions, so re-solves
+// (e.g. after small reward changes) sweep fewer actions. States the policy
+// doesn't cover keep all their actions. Values and Policy are copied over.
+func (m *MDP) PruneUnusedActions() *MDP {
+	used := m.UsedActions()
+	pruned := NewMDP(append([]State(nil), m.States...), m.Discount)
+	pruned.Tolerance = m.Tolerance
+	pruned.MaxIterations = m.MaxIterations
+	pruned.RewardClip = m.RewardClip
+
+	for _, s := range m.States {
+		actions, ok := used[s]
+		if !ok {
+			actions = m.actionsFor(s)
+		}
+		for _, a := range actions {
+			pruned.AddAction(s, a, append([]Transition(nil), m.Transitions[s][a]...))
+		}
+	}
+	maps.Copy(pruned.ValueFunc, m.ValueFunc)
+	maps.Copy(pruned.Policy, m.Policy)
+	return pruned
+}
+
+// FindEquivalentStates partitions States into groups of behaviorally
+// identical states (a bisimulation): states in a group have the same actions
+// by name, and each action has the same expected reward and moves the same
+// probability into every group. Groups, singletons included, are found by
+// partition refinement and listed in States order, each in States order.
+func (m *MDP) FindEquivalentStates() [][]State {
+	block := make(map[State]int, len(m.States))
+	for _, s := range m.States {
+		block[s] = 0
+	}
+
+	numBlocks := 1
+	for {
+		ids := make(map[string]int)
+		next := make(map[State]int, len(m.States))
+		for _, s := range m.States {
+			// Keying by the current block keeps the refinement monotone.
+			sig := fmt.Sprintf("%d|%s", block[s], m.bisimSignature(s, block))
+			id, ok := ids[sig]
+			if !ok {
+				id = len(ids)
+				ids[sig] = id
+			}
+			next[s] = id
+		}
+		block = next
+		if len(ids) == numBlocks {
+			break
+		}
+		numBlocks = len(ids)
+	}
+
+	groups := make([][]State, numBlocks)
+	for _, s := range m.States {
+		groups[block[s]] = append(groups[block[s]], s)
+	}
+	return groups
+}
+
+// bisimSignature describes s's actions in terms of the blocks they reach;
+// successors outside States keep their own name.
+func (m *MDP) bisimSignature(s State, block map[State]int) string {
+	actions := slices.Clone(m.actionsFor(s))
+	slices.Sort(actions)
+	var sb strings.Builder
+	for _, a := range actions {
+		reward := 0.0
+		mass := make(map[string]float64)
+		for _, t := range m.Transitions[s][a] {
+			reward += t.Prob * m.reward(t)
+			key := "=" + string(t.NextState)
+			if b, ok := block[t.NextState]; ok {
+				key = strconv.Itoa(b)
+			}
+			mass[key] += t.Prob
+		}
+		fmt.Fprintf(&sb, "%s:%.9g", a, reward)
+		for _, key := range slices.Sorted(maps.Keys(mass)) {
+			fmt.Fprintf(&sb, ",%s=%.9g", key, mass[key])
+		}
+		sb.WriteByte(';')
+	}
+	return sb.String()
+}
+
+// QuotientMDP returns a smaller MDP with one state per FindEquivalentStates
+// group, named after the group's first state. Each action keeps its
+// probability into every group with the probability-weighted mean reward, so
+// a state's value matches its group's value in the original. Rewards are
+// taken after RewardClip, which the quotient doesn't carry over.
+func (m *MDP) QuotientMDP() *MDP {
+	groups := m.FindEquivalentStates()
+	rep := make(map[State]State, len(m.States))
+	var states []State
+	for _, g := range groups {
+		states = append(states, g[0])
+		for _, s := range g {
+			rep[s] = g[0]
+		}
+	}
+
+	q := NewMDP(states, m.Discount)
+	q.Tolerance = m.Tolerance
+	q.MaxIterations = m.MaxIterations
+	for _, s := range states {
+		for _, a := range m.actionsFor(s) {
+			var targets []State
+			prob := make(map[State]float64)
+			reward := make(map[State]float64)
+			for _, t := range m.Transitions[s][a] {
+				next, ok := rep[t.NextState]
+				if !ok {
+					next = t.NextState
+				}
+				if _, seen := prob[next]; !seen {
+					targets = append(targets, next)
+				}
+				prob[next] += t.Prob
+				reward[next] += t.Prob * m.reward(t)
+			}
+			var ts []Transition
+			for _, next := range targets {
+				r := 0.0
+				if prob[next] > 0 {
+					r = reward[next] / prob[next]
+				}
+				ts = append(ts, Transition{NextState: next, Prob: prob[next], Reward: r})
+			}
+			q.AddAction(s, a, ts)
+		}
+	}
+	return q
+}
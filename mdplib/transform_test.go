@@ -0,0 +1,127 @@
+package mdplib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubMDPReroutesExits(t *testing.T) {
+	m := NewMDP([]State{"a", "b", "c", "d"}, 0.9)
+	m.AddAction("a", "go", []Transition{
+		{NextState: "b", Prob: 0.5, Reward: 1},
+		{NextState: "c", Prob: 0.3, Reward: 2},
+		{NextState: "d", Prob: 0.2, Reward: 7},
+	})
+	m.AddAction("b", "back", []Transition{{NextState: "a", Prob: 1, Reward: 0}})
+	m.AddAction("c", "go", []Transition{{NextState: "d", Prob: 1, Reward: 1}})
+
+	sub := m.SubMDP([]State{"a", "b"})
+	if len(sub.States) != 3 || sub.States[2] != ExitState {
+		t.Fatalf("States = %v, want [a b EXIT]", sub.States)
+	}
+	if _, ok := sub.Transitions["c"]; ok {
+		t.Error("sub-MDP kept transitions of a dropped state")
+	}
+	if len(sub.actionsFor(ExitState)) != 0 {
+		t.Error("ExitState has actions")
+	}
+
+	ts := sub.Transitions["a"]["go"]
+	if len(ts) != 2 || ts[0].NextState != "b" || ts[1].NextState != ExitState {
+		t.Fatalf("a/go transitions = %+v, want b then EXIT", ts)
+	}
+	// The exits to c and d merge into probability 0.5 with the
+	// probability-weighted mean reward (0.3·2 + 0.2·7) / 0.5 = 4.
+	if !near(ts[1].Prob, 0.5, 1e-12) || !near(ts[1].Reward, 4, 1e-12) {
+		t.Errorf("exit transition = %+v, want Prob 0.5 and Reward 4", ts[1])
+	}
+	if got := sub.Transitions["b"]["back"]; len(got) != 1 || got[0].NextState != "a" {
+		t.Errorf("b/back transitions = %+v, want unchanged", got)
+	}
+}
+
+func TestSubMDPWithoutExits(t *testing.T) {
+	m := NewMDP([]State{"a", "b"}, 0.9)
+	m.AddAction("a", "go", []Transition{{NextState: "b", Prob: 1, Reward: 1}})
+	sub := m.SubMDP([]State{"a", "b"})
+	if len(sub.States) != 2 {
+		t.Errorf("States = %v, want no ExitState when nothing leaves the set", sub.States)
+	}
+}
+
+func TestPruneUnusedActions(t *testing.T) {
+	m := NewGridWorld(3, 4, [][2]int{{1, 1}}, [2]int{0, 3}, -0.1, 1, 0.2)
+	m.Tolerance = 1e-12
+	m.ValueIteration()
+	m.ExtractPolicy()
+	m.Policy["stray"] = Up // not a state, must be ignored
+
+	used := m.UsedActions()
+	for _, s := range m.States {
+		if s == GridState(0, 3) {
+			if _, ok := used[s]; ok {
+				t.Error("the terminal goal has a used action")
+			}
+			continue
+		}
+		if got := used[s]; len(got) != 1 || got[0] != m.Policy[s] {
+			t.Errorf("UsedActions[%s] = %v, want [%s]", s, got, m.Policy[s])
+		}
+	}
+
+	pruned := m.PruneUnusedActions()
+	if _, actions, _ := pruned.Size(); actions != len(m.States)-1 {
+		t.Errorf("pruned MDP has %d actions, want one per non-terminal state", actions)
+	}
+	if _, actions, _ := m.Size(); actions != 4*(len(m.States)-1) {
+		t.Error("pruning modified the original MDP")
+	}
+
+	pruned.ValueFunc = make(map[State]float64)
+	pruned.ValueIteration()
+	pruned.ExtractPolicy()
+	for _, s := range m.States {
+		if !near(pruned.ValueFunc[s], m.ValueFunc[s], 1e-9) {
+			t.Errorf("V(%s) = %v after pruning, want %v", s, pruned.ValueFunc[s], m.ValueFunc[s])
+		}
+		if pruned.Policy[s] != m.Policy[s] {
+			t.Errorf("Policy[%s] = %q after pruning, want %q", s, pruned.Policy[s], m.Policy[s])
+		}
+	}
+}
+
+func TestQuotientMDPMergesSymmetricStates(t *testing.T) {
+	m := NewMDP([]State{"start", "left", "right", "other", "goal"}, 0.9)
+	m.AddAction("start", "go", []Transition{
+		{NextState: "left", Prob: 0.4},
+		{NextState: "right", Prob: 0.4},
+		{NextState: "other", Prob: 0.2},
+	})
+	for s, reward := range map[State]float64{"left": 3, "right": 3, "other": 4} {
+		m.AddAction(s, "go", []Transition{{NextState: "goal", Prob: 1, Reward: reward}})
+	}
+
+	groups := m.FindEquivalentStates()
+	want := [][]State{{"start"}, {"left", "right"}, {"other"}, {"goal"}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Fatalf("FindEquivalentStates = %v, want %v", groups, want)
+	}
+
+	q := m.QuotientMDP()
+	if !reflect.DeepEqual(q.States, []State{"start", "left", "other", "goal"}) {
+		t.Fatalf("quotient States = %v", q.States)
+	}
+	if ts := q.Transitions["start"]["go"]; len(ts) != 2 || ts[0].NextState != "left" || !near(ts[0].Prob, 0.8, 1e-12) {
+		t.Errorf("quotient start go = %+v, want 0.8 into the merged left/right state", ts)
+	}
+
+	m.ValueIteration()
+	q.ValueIteration()
+	for _, g := range groups {
+		for _, s := range g {
+			if !near(q.ValueFunc[g[0]], m.ValueFunc[s], 1e-9) {
+				t.Errorf("V(%s) = %v, but its quotient state %s is worth %v", s, m.ValueFunc[s], g[0], q.ValueFunc[g[0]])
+			}
+		}
+	}
+}
@@ -136,28 +136,21 @@ func (s Swish) Derivative(x float64) float64 {
 // Softmax activation (vector only)
 // --------------------
 type Softmax struct {
+	// FullJacobian makes training backpropagate through the softmax
+	// Jacobian, for losses other than CrossEntropyLoss. By default the loss
+	// gradient is passed straight to the pre-activations, which is exact only
+	// for the fused softmax + cross-entropy combination.
+	FullJacobian bool
+
 	lastOutput []float64
 }
 
 // ActivateVector applies softmax over input slice and returns probabilities
 func (s *Softmax) ActivateVector(input []float64) []float64 {
-	maxVal := input[0]
-	for _, v := range input {
-		if v > maxVal {
-			maxVal = v
-		}
-	}
-
-	expSum := 0.0
+	lse := LogSumExp(input) // numerical stability trick lives here
 	output := make([]float64, len(input))
 	for i, v := range input {
-		exp := math.Exp(v - maxVal) // numerical stability trick
-		output[i] = exp
-		expSum += exp
-	}
-
-	for i := range output {
-		output[i] /= expSum
+		output[i] = math.Exp(v - lse)
 	}
 
 	s.lastOutput = output
@@ -174,6 +167,34 @@ func (s *Softmax) Derivative(x float64) float64 {
 	return 1
 }
 
+// SoftmaxJacobian returns the S×S Jacobian diag(p) - p·pᵀ of softmax at the
+// probabilities output, i.e. J[i][j] = dp_i/dz_j.
+func SoftmaxJacobian(output []float64) [][]float64 {
+	jac := make([][]float64, len(output))
+	for i, pi := range output {
+		jac[i] = make([]float64, len(output))
+		for j, pj := range output {
+			jac[i][j] = -pi * pj
+		}
+		jac[i][i] += pi
+	}
+	return jac
+}
+
+// softmaxBackward maps a gradient with respect to the softmax output to its
+// input, Jᵀ·grad, without forming the Jacobian
+func softmaxBackward(output, grad []float64) []float64 {
+	dot := 0.0
+	for k := range output {
+		dot += output[k] * grad[k]
+	}
+	deltas := make([]float64, len(output))
+	for k := range output {
+		deltas[k] = output[k] * (grad[k] - dot)
+	}
+	return deltas
+}
+
 // --------------------
 // Vector helper to apply scalar activation elementwise
 // --------------------
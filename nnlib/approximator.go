@@ -0,0 +1,116 @@
+package nnlib
+
+import (
+	"fmt"
+	"math"
+)
+
+// FeatureEncoder maps a continuous input to a feature vector.
+type FeatureEncoder interface {
+	Encode(x []float64) []float64
+}
+
+// RBFEncoder encodes x as Gaussian radial basis activations
+// exp(-||x-c||² / (2·Width²)) around each center, plus a constant bias feature.
+type RBFEncoder struct {
+	Centers [][]float64
+	Width   float64
+}
+
+// NewRBFGrid places perDim evenly spaced centers along each dimension of the
+// box [low, high] (perDim^len(low) centers in total).
+func NewRBFGrid(low, high []float64, perDim int, width float64) *RBFEncoder {
+	centers := [][]float64{{}}
+	for d := range low {
+		var next [][]float64
+		for _, prefix := range centers {
+			for k := 0; k < perDim; k++ {
+				v := low[d]
+				if perDim > 1 {
+					v += (high[d] - low[d]) * float64(k) / float64(perDim-1)
+				}
+				next = append(next, append(append([]float64(nil), prefix...), v))
+			}
+		}
+		centers = next
+	}
+	return &RBFEncoder{Centers: centers, Width: width}
+}
+
+func (e *RBFEncoder) Encode(x []float64) []float64 {
+	features := make([]float64, len(e.Centers)+1)
+	for i, c := range e.Centers {
+		dist := 0.0
+		for j := range c {
+			d := x[j] - c[j]
+			dist += d * d
+		}
+		features[i] = math.Exp(-dist / (2 * e.Width * e.Width))
+	}
+	features[len(e.Centers)] = 1
+	return features
+}
+
+// LinearApproximator is a linear function of encoded features, a lightweight
+// alternative to a NeuralNetwork for smooth low-dimensional value functions.
+type LinearApproximator struct {
+	Encoder FeatureEncoder
+	Weights []float64
+}
+
+func NewLinearApproximator(encoder FeatureEncoder) *LinearApproximator {
+	return &LinearApproximator{Encoder: encoder}
+}
+
+// Predict returns the approximated value at x
+func (a *LinearApproximator) Predict(x []float64) float64 {
+	features := a.Encoder.Encode(x)
+	a.ensureWeights(len(features))
+	v, _ := Dot(a.Weights, features)
+	return v
+}
+
+// Update takes one gradient step on the squared error (target - Predict(x))²/2
+// and returns the error before the step
+func (a *LinearApproximator) Update(x []float64, target, learningRate float64) float64 {
+	features := a.Encoder.Encode(x)
+	a.ensureWeights(len(features))
+	v, _ := Dot(a.Weights, features)
+	err := target - v
+	for i, f := range features {
+		a.Weights[i] += learningRate * err * f
+	}
+	return err
+}
+
+// Fit runs epochs passes of Update over the samples and returns the final
+// mean squared error
+func (a *LinearApproximator) Fit(inputs [][]float64, targets []float64, epochs int, learningRate float64) float64 {
+	for epoch := 0; epoch < epochs; epoch++ {
+		for i, x := range inputs {
+			a.Update(x, targets[i], learningRate)
+		}
+	}
+	if len(inputs) == 0 {
+		return 0
+	}
+	mse := 0.0
+	for i, x := range inputs {
+		d := targets[i] - a.Predict(x)
+		mse += d * d
+	}
+	return mse / float64(len(inputs))
+}
+
+// ensureWeights allocates zero weights on first use and panics if the
+// encoder's feature count no longer matches them, rather than silently
+// discarding what was learned
+func (a *LinearApproximator) ensureWeights(n int) {
+	if a.Weights == nil {
+		a.Weights = make([]float64, n)
+		return
+	}
+	if len(a.Weights) != n {
+		panic(fmt.Sprintf("LinearApproximator: encoder produced %d features but there are %d weights", n, len(a.Weights)))
+	}
+}
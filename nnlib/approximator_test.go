@@ -0,0 +1,62 @@
+package nnlib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinearApproximatorFitsQuadratic(t *testing.T) {
+	// V(x, y) = x² + 0.5·y² on [-1, 1]².
+	value := func(x []float64) float64 { return x[0]*x[0] + 0.5*x[1]*x[1] }
+	var inputs [][]float64
+	var targets []float64
+	for i := 0; i <= 10; i++ {
+		for j := 0; j <= 10; j++ {
+			x := []float64{-1 + 0.2*float64(i), -1 + 0.2*float64(j)}
+			inputs = append(inputs, x)
+			targets = append(targets, value(x))
+		}
+	}
+
+	a := NewLinearApproximator(NewRBFGrid([]float64{-1, -1}, []float64{1, 1}, 5, 0.5))
+	if mse := a.Fit(inputs, targets, 500, 0.05); mse > 1e-3 {
+		t.Errorf("training MSE = %v, want below 1e-3", mse)
+	}
+	for _, x := range [][]float64{{0.3, -0.7}, {-0.55, 0.15}, {0.9, 0.9}} {
+		if got, want := a.Predict(x), value(x); !approx(got, want, 0.05) {
+			t.Errorf("Predict(%v) = %v, want %v", x, got, want)
+		}
+	}
+}
+
+func TestRBFGridCenters(t *testing.T) {
+	e := NewRBFGrid([]float64{0, 10}, []float64{1, 20}, 3, 1)
+	if len(e.Centers) != 9 {
+		t.Fatalf("len(Centers) = %d, want 9", len(e.Centers))
+	}
+	if c := e.Centers[5]; c[0] != 0.5 || c[1] != 20 {
+		t.Errorf("Centers[5] = %v, want [0.5 20]", c)
+	}
+	f := e.Encode([]float64{0.5, 20})
+	if len(f) != 10 || f[5] != 1 || f[9] != 1 {
+		t.Errorf("Encode at a center = %v, want 1 at that center and the bias", f)
+	}
+}
+
+func TestLinearApproximatorRejectsFeatureChange(t *testing.T) {
+	a := NewLinearApproximator(NewRBFGrid([]float64{0}, []float64{1}, 3, 0.5))
+	a.Update([]float64{0.5}, 1, 0.1)
+	learned := append([]float64(nil), a.Weights...)
+
+	a.Encoder = NewRBFGrid([]float64{0}, []float64{1}, 5, 0.5)
+	msg := expectPanic(t, func() { a.Predict([]float64{0.5}) })
+	if !strings.Contains(msg, "6 features but there are 4 weights") {
+		t.Errorf("panic = %q", msg)
+	}
+	expectPanic(t, func() { a.Update([]float64{0.5}, 1, 0.1) })
+	for i := range learned {
+		if a.Weights[i] != learned[i] {
+			t.Fatalf("weights = %v after the mismatch, want %v kept", a.Weights, learned)
+		}
+	}
+}
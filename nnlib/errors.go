@@ -0,0 +1,11 @@
+package nnlib
+
+import "errors"
+
+// Sentinel causes wrapped by loader errors, for use with errors.Is. A missing
+// file surfaces as the underlying os error (errors.Is(err, fs.ErrNotExist)).
+var (
+	ErrMalformedModel = errors.New("malformed model")
+	ErrMalformedNPY   = errors.New("malformed .npy file")
+	ErrShapeMismatch  = errors.New("shape mismatch")
+)
@@ -0,0 +1,25 @@
+package nnlib
+
+// ExpandOutputLayer adds newClasses output neurons to the final layer for
+// continual learning. The new weight rows are drawn from the layer's Init
+// scheme (UniformInit{Limit: 0.1} for loaded layers) and the new biases
+// start at zero, so the logits of the existing classes are unchanged; softmax
+// probabilities renormalize over the larger output.
+func (nn *NeuralNetwork) ExpandOutputLayer(newClasses int) {
+	if len(nn.Layers) == 0 {
+		panic("ExpandOutputLayer: network has no layers")
+	}
+	if newClasses <= 0 {
+		return
+	}
+
+	layer := nn.Layers[len(nn.Layers)-1]
+	var init InitScheme = UniformInit{Limit: 0.1}
+	if layer.Init != nil {
+		init = layer.Init
+	}
+	layer.Weights = append(layer.Weights, init.InitWeights(newClasses, len(layer.Weights[0]))...)
+	layer.Biases = append(layer.Biases, make([]float64, newClasses)...)
+	layer.resetDerived()
+	nn.resetPolyak()
+}
@@ -0,0 +1,44 @@
+package nnlib
+
+import "testing"
+
+func TestExpandOutputLayerKeepsExistingLogits(t *testing.T) {
+	SetSeed(3)
+	nn := NewMLP(4, []int{6}, 3, Tanh{}, &Softmax{})
+	inputs := [][]float64{{0.1, -0.4, 0.9, 0.2}, {-1, 0.5, 0.3, 0.7}}
+	before := make([][]float64, len(inputs))
+	for i, x := range inputs {
+		before[i] = nn.Logits(x)
+	}
+
+	nn.ExpandOutputLayer(2)
+	for i, x := range inputs {
+		logits := nn.Logits(x)
+		if len(logits) != 5 {
+			t.Fatalf("output dimension = %d after expanding by 2, want 5", len(logits))
+		}
+		for k, want := range before[i] {
+			if logits[k] != want {
+				t.Errorf("input %d: logit %d = %v after expansion, want %v", i, k, logits[k], want)
+			}
+		}
+		if p := nn.Predict(x); len(p) != 5 || !approx(Sum(p), 1, 1e-12) {
+			t.Errorf("input %d: Predict = %v, want 5 probabilities summing to 1", i, p)
+		}
+	}
+
+	// The new rows must be trainable like the old ones.
+	prior := nn.Predict(inputs[0])[4]
+	target := []float64{0, 0, 0, 0, 1}
+	if err := nn.Train(inputs[0], target, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if p := nn.Predict(inputs[0])[4]; p <= prior {
+		t.Errorf("new class probability %v did not rise from %v after training on it", p, prior)
+	}
+
+	nn.ExpandOutputLayer(0)
+	if got := len(nn.Predict(inputs[0])); got != 5 {
+		t.Errorf("ExpandOutputLayer(0) changed the output dimension to %d", got)
+	}
+}
@@ -0,0 +1,194 @@
+package nnlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// FitConfig controls the mini-batch training loop run by Fit.
+type FitConfig struct {
+	Epochs       int
+	BatchSize    int // 0 trains on the full dataset each step
+	LearningRate float64
+	Loss         LossFunc // defaults to CrossEntropyLoss
+	Shuffle      bool
+	Rand         *rand.Rand // used for shuffling; seeded from the clock when nil
+
+	// Stratify orders each epoch so every batch holds classes (argmax of the
+	// one-hot targets) in roughly their overall proportions.
+	Stratify bool
+
+	// Schedule, when set, gives the learning rate of each epoch in place of
+	// LearningRate.
+	Schedule Scheduler
+
+	// Augment, when set, transforms every training input (e.g. adding noise
+	// or jitter) each time it is fed to a batch, using Rand. It receives a
+	// copy it may modify. Only Fit's training passes are affected.
+	Augment func(x []float64, rng *rand.Rand) []float64
+}
+
+// Fit trains the network for cfg.Epochs passes over the data and returns the
+// mean training loss of each epoch.
+func (nn *NeuralNetwork) Fit(inputs, targets [][]float64, cfg FitConfig) ([]float64, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("Fit: no training samples")
+	}
+	if len(inputs) != len(targets) {
+		return nil, errors.New("Fit: inputs and targets must be the same length")
+	}
+	for i := range inputs {
+		if len(inputs[i]) != nn.InputSize() {
+			return nil, fmt.Errorf("Fit: input %d has %d values but the network expects %d", i, len(inputs[i]), nn.InputSize())
+		}
+		if len(targets[i]) != nn.OutputSize() {
+			return nil, fmt.Errorf("Fit: target %d has %d values but the network outputs %d", i, len(targets[i]), nn.OutputSize())
+		}
+	}
+
+	loss := cfg.Loss
+	if loss == nil {
+		loss = CrossEntropyLoss
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 || batchSize > len(inputs) {
+		batchSize = len(inputs)
+	}
+	rng := cfg.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	order := make([]int, len(inputs))
+	for i := range order {
+		order[i] = i
+	}
+
+	history := make([]float64, 0, cfg.Epochs)
+	for epoch := 0; epoch < cfg.Epochs; epoch++ {
+		if cfg.Shuffle {
+			rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		}
+		if cfg.Stratify {
+			stratify(order, targets)
+		}
+
+		learningRate := cfg.LearningRate
+		if cfg.Schedule != nil {
+			learningRate = cfg.Schedule.Rate(epoch)
+		}
+
+		epochLoss := 0.0
+		for start := 0; start < len(order); start += batchSize {
+			end := min(start+batchSize, len(order))
+			batchInputs := make([][]float64, 0, end-start)
+			batchTargets := make([][]float64, 0, end-start)
+			for _, idx := range order[start:end] {
+				input := inputs[idx]
+				if cfg.Augment != nil {
+					input = cfg.Augment(append([]float64(nil), input...), rng)
+				}
+				batchInputs = append(batchInputs, input)
+				batchTargets = append(batchTargets, targets[idx])
+			}
+			batchLoss, err := nn.trainBatch(batchInputs, batchTargets, learningRate, loss)
+			if err != nil {
+				return history, fmt.Errorf("Fit: epoch %d: %w", epoch, err)
+			}
+			epochLoss += batchLoss * float64(end-start)
+		}
+		history = append(history, epochLoss/float64(len(inputs)))
+	}
+	return history, nil
+}
+
+// stratify reorders sample indices so classes are spread evenly through the
+// sequence: the k-th of n samples of a class is placed at position (k+0.5)/n,
+// and samples are sorted by that position. Order within a class is kept, so
+// a preceding shuffle still randomizes which samples land in which batch.
+func stratify(order []int, targets [][]float64) {
+	byClass := make(map[int][]int)
+	var classes []int
+	for _, idx := range order {
+		c := ArgMax(targets[idx])
+		if _, ok := byClass[c]; !ok {
+			classes = append(classes, c)
+		}
+		byClass[c] = append(byClass[c], idx)
+	}
+
+	type placed struct {
+		idx int
+		pos float64
+	}
+	all := make([]placed, 0, len(order))
+	for _, c := range classes {
+		members := byClass[c]
+		for k, idx := range members {
+			all = append(all, placed{idx, (float64(k) + 0.5) / float64(len(members))})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].pos < all[j].pos })
+	for i, p := range all {
+		order[i] = p.idx
+	}
+}
+
+// TrainRegression fits a network with a Linear output layer under MSELoss and
+// returns the coefficient of determination (R²) on the validation set.
+// cfg.Loss is ignored.
+func TrainRegression(nn *NeuralNetwork, inputs, targets, valInputs, valTargets [][]float64, cfg FitConfig) (float64, error) {
+	if len(nn.Layers) == 0 {
+		return 0, errors.New("TrainRegression: network has no layers")
+	}
+	if _, ok := nn.Layers[len(nn.Layers)-1].Activation.(Linear); !ok {
+		return 0, errors.New("TrainRegression: output layer must use Linear activation")
+	}
+	if len(valInputs) == 0 || len(valInputs) != len(valTargets) {
+		return 0, errors.New("TrainRegression: validation inputs and targets must be non-empty and the same length")
+	}
+
+	cfg.Loss = MSELoss
+	if _, err := nn.Fit(inputs, targets, cfg); err != nil {
+		return 0, err
+	}
+
+	preds := make([][]float64, len(valInputs))
+	for i, input := range valInputs {
+		preds[i] = nn.Predict(input)
+	}
+	return RSquared(preds, valTargets), nil
+}
+
+// LRFind runs the learning-rate range test: steps full-batch cross-entropy
+// updates with the rate growing geometrically from minLR to maxLR, recording
+// the loss at each rate. Pick a rate somewhat below where the loss starts to
+// diverge. The weights and biases are restored afterwards; the trial steps
+// neither feed the Polyak average nor reach the OnGradient callback.
+func (nn *NeuralNetwork) LRFind(inputs, targets [][]float64, minLR, maxLR float64, steps int) (rates, losses []float64) {
+	weights, biases := nn.snapshot()
+	updates := nn.updates
+	shadowWeights, shadowBiases, onGradient := nn.shadowWeights, nn.shadowBiases, nn.onGradient
+	nn.shadowWeights, nn.shadowBiases, nn.onGradient = nil, nil, nil
+	defer func() {
+		nn.restore(weights, biases)
+		nn.updates = updates
+		nn.shadowWeights, nn.shadowBiases, nn.onGradient = shadowWeights, shadowBiases, onGradient
+	}()
+
+	rates = make([]float64, steps)
+	losses = make([]float64, steps)
+	for i := range rates {
+		rates[i] = minLR
+		if steps > 1 {
+			rates[i] = minLR * math.Pow(maxLR/minLR, float64(i)/float64(steps-1))
+		}
+		// A rejected non-finite update still reports the loss it was taken at.
+		losses[i], _ = nn.trainBatch(inputs, targets, rates[i], CrossEntropyLoss)
+	}
+	return rates, losses
+}
@@ -0,0 +1,203 @@
+package nnlib
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTrainRegressionLinearFunction(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	sample := func(n int) (inputs, targets [][]float64) {
+		for i := 0; i < n; i++ {
+			x, y := rng.Float64()*2-1, rng.Float64()*2-1
+			inputs = append(inputs, []float64{x, y})
+			targets = append(targets, []float64{2*x - 3*y + 0.5, -x + y})
+		}
+		return inputs, targets
+	}
+	inputs, targets := sample(200)
+	valInputs, valTargets := sample(50)
+
+	nn := NewNeuralNetwork([]int{2, 2}, []ActivationFunc{Linear{}})
+	r2, err := TrainRegression(nn, inputs, targets, valInputs, valTargets, FitConfig{
+		Epochs: 200, BatchSize: 20, LearningRate: 0.05, Shuffle: true, Rand: rng,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2 < 0.99 {
+		t.Errorf("R² = %v, want above 0.99", r2)
+	}
+}
+
+func TestTrainRegressionRequiresLinearOutput(t *testing.T) {
+	nn := NewNeuralNetwork([]int{2, 1}, []ActivationFunc{Sigmoid{}})
+	data := [][]float64{{0, 0}}
+	if _, err := TrainRegression(nn, data, [][]float64{{0}}, data, [][]float64{{0}}, FitConfig{Epochs: 1}); err == nil {
+		t.Error("TrainRegression accepted a Sigmoid output layer")
+	}
+}
+
+func TestFitStratifiedBatches(t *testing.T) {
+	// 30 samples of class 0 then 10 of class 1. The loss sees each batch's
+	// targets in order and records their classes.
+	var inputs, targets [][]float64
+	for i := 0; i < 40; i++ {
+		class := 0
+		if i >= 30 {
+			class = 1
+		}
+		target := []float64{0, 0}
+		target[class] = 1
+		inputs = append(inputs, []float64{float64(class)})
+		targets = append(targets, target)
+	}
+
+	var seen []float64
+	nn := NewNeuralNetwork([]int{1, 2}, []ActivationFunc{&Softmax{}})
+	_, err := nn.Fit(inputs, targets, FitConfig{
+		Epochs: 3, BatchSize: 8, LearningRate: 0.1, Shuffle: true, Stratify: true,
+		Rand: rand.New(rand.NewSource(1)),
+		Loss: func(predicted, target []float64) (float64, []float64) {
+			seen = append(seen, target[1])
+			return CrossEntropyLoss(predicted, target)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// At 3:1, every batch of 8 should hold 2 samples of class 1.
+	for start := 0; start < len(seen); start += 8 {
+		minority := 0
+		for _, c := range seen[start : start+8] {
+			minority += int(c)
+		}
+		if minority != 2 {
+			t.Errorf("batch at %d has %d class-1 samples, want 2: %v", start, minority, seen[start:start+8])
+		}
+	}
+}
+
+// blobs returns a small two-class dataset of separated Gaussian blobs.
+func blobs(rng *rand.Rand, n int) (inputs, targets [][]float64) {
+	for i := 0; i < n; i++ {
+		class := i % 2
+		center := float64(2*class - 1)
+		inputs = append(inputs, []float64{center + 0.3*rng.NormFloat64(), -center + 0.3*rng.NormFloat64()})
+		target := []float64{0, 0}
+		target[class] = 1
+		targets = append(targets, target)
+	}
+	return inputs, targets
+}
+
+func TestLRFind(t *testing.T) {
+	SetSeed(1)
+	inputs, targets := blobs(rand.New(rand.NewSource(1)), 40)
+	nn := NewMLP(2, []int{4}, 2, Tanh{}, &Softmax{})
+	weights, biases := nn.snapshot()
+
+	rates, losses := nn.LRFind(inputs, targets, 1e-4, 10, 9)
+	if len(rates) != 9 || len(losses) != 9 {
+		t.Fatalf("got %d rates and %d losses, want 9 each", len(rates), len(losses))
+	}
+	for i, r := range rates {
+		if want := 1e-4 * math.Pow(10, float64(i)*5/8); !approx(r, want, 1e-12*want) {
+			t.Errorf("rates[%d] = %v, want %v", i, r, want)
+		}
+	}
+	if losses[len(losses)-2] >= losses[0] {
+		t.Errorf("losses = %v, want the larger rates to reduce the loss", losses)
+	}
+	if w, b := nn.snapshot(); !reflect.DeepEqual(w, weights) || !reflect.DeepEqual(b, biases) {
+		t.Error("LRFind did not restore the weights")
+	}
+	if nn.updates != 0 {
+		t.Errorf("updates = %d after LRFind, want 0", nn.updates)
+	}
+}
+
+func TestLRFindLeavesPolyakAndHook(t *testing.T) {
+	SetSeed(1)
+	inputs, targets := blobs(rand.New(rand.NewSource(1)), 40)
+	nn := NewMLP(2, []int{4}, 2, Tanh{}, &Softmax{})
+	nn.EnablePolyakAveraging(0.9)
+	if err := nn.TrainBatch(inputs, targets, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	shadowWeights := clone3(nn.shadowWeights)
+	shadowBiases := clone2(nn.shadowBiases)
+	calls := 0
+	nn.OnGradient(func(float64) { calls++ })
+
+	nn.LRFind(inputs, targets, 1e-4, 10, 9)
+	if !reflect.DeepEqual(nn.shadowWeights, shadowWeights) || !reflect.DeepEqual(nn.shadowBiases, shadowBiases) {
+		t.Error("LRFind trial steps moved the Polyak average")
+	}
+	if calls != 0 {
+		t.Errorf("OnGradient ran %d times during LRFind, want 0", calls)
+	}
+
+	if err := nn.TrainBatch(inputs, targets, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || reflect.DeepEqual(nn.shadowWeights, shadowWeights) {
+		t.Error("the hook or the Polyak average was not reinstated after LRFind")
+	}
+}
+
+func clone2(s [][]float64) [][]float64 {
+	out := make([][]float64, len(s))
+	for i, row := range s {
+		out[i] = append([]float64(nil), row...)
+	}
+	return out
+}
+
+func clone3(s [][][]float64) [][][]float64 {
+	out := make([][][]float64, len(s))
+	for i, m := range s {
+		out[i] = clone2(m)
+	}
+	return out
+}
+
+func TestFitAugmentOnlyDuringTraining(t *testing.T) {
+	inputs := [][]float64{{-2}, {-1}, {0}, {1}, {2}}
+	targets := [][]float64{{-2}, {-1}, {0}, {1}, {2}}
+	calls := 0
+	negate := func(x []float64, _ *rand.Rand) []float64 {
+		calls++
+		x[0] = -x[0]
+		return x
+	}
+
+	nn := NewNeuralNetwork([]int{1, 1}, []ActivationFunc{Linear{}})
+	_, err := nn.Fit(inputs, targets, FitConfig{
+		Epochs: 200, LearningRate: 0.05, Loss: MSELoss, Augment: negate,
+		Rand: rand.New(rand.NewSource(1)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 200*len(inputs) {
+		t.Errorf("Augment ran %d times, want once per sample per epoch (%d)", calls, 200*len(inputs))
+	}
+	if inputs[0][0] != -2 || inputs[4][0] != 2 {
+		t.Errorf("Augment modified the caller's inputs: %v", inputs)
+	}
+	// Trained on negated inputs, the network learned y = -x.
+	if got := nn.Predict([]float64{1})[0]; !approx(got, -1, 1e-3) {
+		t.Errorf("Predict(1) = %v, want -1 from the augmented training data", got)
+	}
+
+	calls = 0
+	nn.Evaluate(inputs, targets)
+	nn.PerSampleLosses(inputs, targets, MSELoss)
+	if calls != 0 {
+		t.Errorf("Augment ran %d times outside training", calls)
+	}
+}
@@ -0,0 +1,52 @@
+package nnlib
+
+import "math"
+
+// GradientVariance computes every sample's gradient under loss (nil means
+// CrossEntropyLoss) and returns the mean per-sample gradient norm and the
+// total variance of the gradients around their batch mean,
+// (1/N) Σ ||g_i - ḡ||². A variance large relative to ||ḡ||² suggests a larger
+// batch. Weights are not modified.
+func (nn *NeuralNetwork) GradientVariance(inputs, targets [][]float64, loss LossFunc) (meanNorm, variance float64) {
+	if len(inputs) == 0 {
+		return 0, 0
+	}
+	if loss == nil {
+		loss = CrossEntropyLoss
+	}
+
+	grads := make([][]float64, len(inputs))
+	for i := range inputs {
+		_, weightGrads, biasGrads := nn.batchGradients(inputs[i:i+1], targets[i:i+1], loss)
+		grads[i] = flattenGradients(weightGrads, biasGrads)
+		sq, _ := Dot(grads[i], grads[i])
+		meanNorm += math.Sqrt(sq)
+	}
+	meanNorm /= float64(len(inputs))
+
+	mean := make([]float64, len(grads[0]))
+	for _, g := range grads {
+		for k, v := range g {
+			mean[k] += v / float64(len(grads))
+		}
+	}
+	for _, g := range grads {
+		for k, v := range g {
+			d := v - mean[k]
+			variance += d * d
+		}
+	}
+	return meanNorm, variance / float64(len(grads))
+}
+
+// flattenGradients concatenates weight and bias gradients layer by layer
+func flattenGradients(weightGrads [][][]float64, biasGrads [][]float64) []float64 {
+	var flat []float64
+	for i := range weightGrads {
+		for _, row := range weightGrads[i] {
+			flat = append(flat, row...)
+		}
+		flat = append(flat, biasGrads[i]...)
+	}
+	return flat
+}
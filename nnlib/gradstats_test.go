@@ -0,0 +1,39 @@
+package nnlib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGradientVariance(t *testing.T) {
+	nn := NewNeuralNetwork([]int{1, 1}, []ActivationFunc{Linear{}})
+	nn.Layers[0].Weights = [][]float64{{0}}
+	nn.Layers[0].Biases = []float64{0}
+	inputs := [][]float64{{1}, {2}, {0}}
+	targets := [][]float64{{1}, {0.5}, {-1}}
+
+	// With output 0, MSE gives dL/dy = -2·y, so each sample's gradient is
+	// (dW, dB) = (-2·y·x, -2·y).
+	grads := [][2]float64{{-2, -2}, {-2, -1}, {0, 2}}
+	wantNorm, mean := 0.0, [2]float64{}
+	for _, g := range grads {
+		wantNorm += math.Hypot(g[0], g[1]) / 3
+		mean[0] += g[0] / 3
+		mean[1] += g[1] / 3
+	}
+	wantVariance := 0.0
+	for _, g := range grads {
+		wantVariance += ((g[0]-mean[0])*(g[0]-mean[0]) + (g[1]-mean[1])*(g[1]-mean[1])) / 3
+	}
+
+	meanNorm, variance := nn.GradientVariance(inputs, targets, MSELoss)
+	if !approx(meanNorm, wantNorm, 1e-12) {
+		t.Errorf("meanNorm = %v, want %v", meanNorm, wantNorm)
+	}
+	if !approx(variance, wantVariance, 1e-12) {
+		t.Errorf("variance = %v, want %v", variance, wantVariance)
+	}
+	if nn.Layers[0].Weights[0][0] != 0 || nn.Layers[0].Biases[0] != 0 {
+		t.Error("GradientVariance modified the weights")
+	}
+}
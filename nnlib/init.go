@@ -0,0 +1,134 @@
+package nnlib
+
+import "math"
+
+// InitScheme fills a new layer's weight matrix (rows = outputs, cols = inputs).
+type InitScheme interface {
+	InitWeights(rows, cols int) [][]float64
+}
+
+// BiasInit fills a new layer's biases given its fan-in (number of inputs).
+type BiasInit interface {
+	InitBiases(fanIn, n int) []float64
+}
+
+// --------------------
+// Uniform init in [-Limit, Limit] (NewLayer uses Limit = 0.1)
+// --------------------
+type UniformInit struct {
+	Limit float64
+}
+
+func (u UniformInit) InitWeights(rows, cols int) [][]float64 {
+	w := make([][]float64, rows)
+	for i := range w {
+		w[i] = make([]float64, cols)
+		for j := range w[i] {
+			w[i][j] = rng.Float64()*2*u.Limit - u.Limit
+		}
+	}
+	return w
+}
+
+// --------------------
+// Orthogonal init: QR of a random Gaussian matrix, scaled by Gain.
+// Square layers get W·Wᵀ = Gain²·I; non-square layers are semi-orthogonal
+// (orthonormal rows when rows <= cols, orthonormal columns otherwise).
+// --------------------
+type OrthogonalInit struct {
+	Gain float64
+}
+
+func (o OrthogonalInit) InitWeights(rows, cols int) [][]float64 {
+	// Orthonormalize the columns of a tall n x k Gaussian matrix, then
+	// transpose if the layer is wide.
+	n, k := rows, cols
+	if rows < cols {
+		n, k = cols, rows
+	}
+	q := make([][]float64, k) // q[c] is column c, length n
+	for c := range q {
+		q[c] = make([]float64, n)
+		for r := range q[c] {
+			q[c][r] = rng.NormFloat64()
+		}
+	}
+	// Modified Gram-Schmidt; the implied R has a positive diagonal, so Q is
+	// uniformly distributed over orthogonal matrices.
+	for c := range q {
+		for p := 0; p < c; p++ {
+			dot := 0.0
+			for r := range q[c] {
+				dot += q[c][r] * q[p][r]
+			}
+			for r := range q[c] {
+				q[c][r] -= dot * q[p][r]
+			}
+		}
+		norm := 0.0
+		for _, v := range q[c] {
+			norm += v * v
+		}
+		norm = math.Sqrt(norm)
+		for r := range q[c] {
+			q[c][r] /= norm
+		}
+	}
+
+	w := make([][]float64, rows)
+	for i := range w {
+		w[i] = make([]float64, cols)
+		for j := range w[i] {
+			if rows < cols {
+				w[i][j] = o.Gain * q[i][j]
+			} else {
+				w[i][j] = o.Gain * q[j][i]
+			}
+		}
+	}
+	return w
+}
+
+// --------------------
+// Zero biases (NewLayer's default)
+// --------------------
+type ZeroBias struct{}
+
+func (ZeroBias) InitBiases(fanIn, n int) []float64 {
+	return make([]float64, n)
+}
+
+// --------------------
+// Constant biases, e.g. a small positive Value to keep ReLUs active early on
+// --------------------
+type ConstantBias struct {
+	Value float64
+}
+
+func (c ConstantBias) InitBiases(fanIn, n int) []float64 {
+	b := make([]float64, n)
+	for i := range b {
+		b[i] = c.Value
+	}
+	return b
+}
+
+// --------------------
+// Uniform biases in [-Limit, Limit]; Limit = 0 uses the fan-in scaled
+// 1/sqrt(fanIn)
+// --------------------
+type UniformBias struct {
+	Limit float64
+}
+
+func (u UniformBias) InitBiases(fanIn, n int) []float64 {
+	limit := u.Limit
+	if limit == 0 && fanIn > 0 {
+		limit = 1 / math.Sqrt(float64(fanIn))
+	}
+	b := make([]float64, n)
+	for i := range b {
+		b[i] = rng.Float64()*2*limit - limit
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+package nnlib
+
+import "testing"
+
+// gram returns A·Aᵀ, or Aᵀ·A when transpose is set.
+func gram(a [][]float64, transpose bool) [][]float64 {
+	if transpose {
+		at := make([][]float64, len(a[0]))
+		for j := range at {
+			at[j] = make([]float64, len(a))
+			for i := range a {
+				at[j][i] = a[i][j]
+			}
+		}
+		a = at
+	}
+	g := make([][]float64, len(a))
+	for i := range a {
+		g[i] = make([]float64, len(a))
+		for j := range a {
+			for k := range a[i] {
+				g[i][j] += a[i][k] * a[j][k]
+			}
+		}
+	}
+	return g
+}
+
+func TestOrthogonalInit(t *testing.T) {
+	SetSeed(1)
+	for _, tc := range []struct {
+		rows, cols int
+		transpose  bool // compare WᵀW for tall layers
+	}{
+		{6, 6, false},
+		{3, 7, false},
+		{7, 3, true},
+	} {
+		const gain = 1.5
+		w := OrthogonalInit{Gain: gain}.InitWeights(tc.rows, tc.cols)
+		g := gram(w, tc.transpose)
+		for i := range g {
+			for j := range g[i] {
+				want := 0.0
+				if i == j {
+					want = gain * gain
+				}
+				if !approx(g[i][j], want, 1e-9) {
+					t.Fatalf("%dx%d: gram[%d][%d] = %v, want %v", tc.rows, tc.cols, i, j, g[i][j], want)
+				}
+			}
+		}
+	}
+}
+
+func TestConstantBiasInit(t *testing.T) {
+	layer := NewLayerWithInit(3, 4, ReLU{}, UniformInit{Limit: 0.1}, ConstantBias{Value: 0.25})
+	for i, b := range layer.Biases {
+		if b != 0.25 {
+			t.Fatalf("bias %d = %v, want 0.25", i, b)
+		}
+	}
+	// A zero input reaches the output through the biases alone.
+	for i, out := range layer.Forward([]float64{0, 0, 0}) {
+		if out != 0.25 {
+			t.Errorf("ReLU output %d on a zero input = %v, want the bias 0.25", i, out)
+		}
+	}
+}
+
+func TestUniformBiasInitUsesFanIn(t *testing.T) {
+	SetSeed(2)
+	layer := NewLayerWithInit(16, 200, Linear{}, UniformInit{Limit: 0.1}, UniformBias{})
+	maxAbs := 0.0
+	for _, b := range layer.Biases {
+		maxAbs = max(maxAbs, b, -b)
+	}
+	// Limit 0 means 1/sqrt(16) = 0.25.
+	if maxAbs > 0.25 || maxAbs < 0.2 {
+		t.Errorf("largest |bias| = %v, want close to but within 0.25", maxAbs)
+	}
+}
@@ -0,0 +1,149 @@
+package nnlib
+
+import (
+	"math"
+	"sort"
+)
+
+// forwardAll runs a forward pass without touching the layer caches and
+// returns every layer's pre-activations and outputs
+func (nn *NeuralNetwork) forwardAll(input []float64) (preActivations, outputs [][]float64) {
+	nn.checkInput(input)
+	preActivations = make([][]float64, len(nn.Layers))
+	outputs = make([][]float64, len(nn.Layers))
+	for i, layer := range nn.Layers {
+		preActivations[i], outputs[i] = layer.compute(input)
+		input = outputs[i]
+	}
+	return preActivations, outputs
+}
+
+// backpropToInput maps a gradient with respect to the network output back to
+// the input using the activations of a forwardAll pass
+func (nn *NeuralNetwork) backpropToInput(preActivations, outputs [][]float64, outputGrad []float64) []float64 {
+	grad := outputGrad
+	for l := len(nn.Layers) - 1; l >= 0; l-- {
+		grad = nn.Layers[l].inputGradient(preActivations[l], outputs[l], grad)
+	}
+	return grad
+}
+
+// Jacobian returns the matrix of dOutput_i/dInput_j at input, computed by
+// backpropagating a one-hot seed from each output through a single cached
+// forward pass. Training caches and weights are not modified.
+func (nn *NeuralNetwork) Jacobian(input []float64) [][]float64 {
+	preActivations, outputs := nn.forwardAll(input)
+	jac := make([][]float64, nn.OutputSize())
+	for i := range jac {
+		seed := make([]float64, nn.OutputSize())
+		seed[i] = 1
+		jac[i] = nn.backpropToInput(preActivations, outputs, seed)
+	}
+	return jac
+}
+
+// InputGradient returns dOutput_k/dInput at input, a saliency map for output
+// k. Training caches and weights are not modified.
+func (nn *NeuralNetwork) InputGradient(input []float64, k int) []float64 {
+	preActivations, outputs := nn.forwardAll(input)
+	seed := make([]float64, nn.OutputSize())
+	seed[k] = 1
+	return nn.backpropToInput(preActivations, outputs, seed)
+}
+
+// IntegratedGradients attributes the network's top output at input to each
+// input feature: the input gradient of that output averaged over steps
+// midpoints on the straight line from baseline to input, times
+// (input - baseline). The attributions sum to approximately the change in
+// that output between baseline and input.
+func (nn *NeuralNetwork) IntegratedGradients(input, baseline []float64, steps int) []float64 {
+	nn.checkInput(baseline)
+	if steps <= 0 {
+		panic("IntegratedGradients: steps must be positive")
+	}
+	_, outputs := nn.forwardAll(input)
+	target := ArgMax(outputs[len(outputs)-1])
+
+	point := make([]float64, len(input))
+	avg := make([]float64, len(input))
+	for step := 0; step < steps; step++ {
+		alpha := (float64(step) + 0.5) / float64(steps)
+		for j := range point {
+			point[j] = baseline[j] + alpha*(input[j]-baseline[j])
+		}
+		for j, g := range nn.InputGradient(point, target) {
+			avg[j] += g / float64(steps)
+		}
+	}
+
+	attributions := make([]float64, len(input))
+	for j := range attributions {
+		attributions[j] = avg[j] * (input[j] - baseline[j])
+	}
+	return attributions
+}
+
+// lossInputGradient returns the loss at input and its gradient with respect
+// to the input. It follows the training convention: a Softmax output layer
+// takes the loss gradient as the gradient of its pre-activations, which is
+// exact for CrossEntropyLoss, unless it sets FullJacobian.
+func (nn *NeuralNetwork) lossInputGradient(input, target []float64, loss LossFunc) (float64, []float64) {
+	preActivations, outputs := nn.forwardAll(input)
+	nn.checkTarget(target)
+	value, grad := loss(outputs[len(outputs)-1], target)
+
+	last := len(nn.Layers) - 1
+	if softmax, ok := nn.Layers[last].Activation.(*Softmax); ok && !softmax.FullJacobian {
+		grad = nn.Layers[last].deltasToInput(grad)
+		last--
+	}
+	for l := last; l >= 0; l-- {
+		grad = nn.Layers[l].inputGradient(preActivations[l], outputs[l], grad)
+	}
+	return value, grad
+}
+
+// FGSM returns the fast gradient sign method adversarial example
+// input + epsilon*sign(∇_input loss) for testing robustness. Features with a
+// zero gradient are left unchanged.
+func (nn *NeuralNetwork) FGSM(input, target []float64, epsilon float64, lossFn LossFunc) []float64 {
+	_, grad := nn.lossInputGradient(input, target, lossFn)
+	adversarial := make([]float64, len(input))
+	for j, g := range grad {
+		switch {
+		case g > 0:
+			adversarial[j] = input[j] + epsilon
+		case g < 0:
+			adversarial[j] = input[j] - epsilon
+		default:
+			adversarial[j] = input[j]
+		}
+	}
+	return adversarial
+}
+
+// FeatureImportance averages |dOutput_k/dInput| over inputs, e.g. the encoded
+// states of an MDP fed to a value network, and returns the per-feature
+// importance with the feature indices ranked from most to least important.
+func (nn *NeuralNetwork) FeatureImportance(inputs [][]float64, k int) (importance []float64, ranking []int) {
+	importance = make([]float64, nn.InputSize())
+	for _, input := range inputs {
+		for j, g := range nn.InputGradient(input, k) {
+			importance[j] += math.Abs(g)
+		}
+	}
+	if len(inputs) > 0 {
+		for j := range importance {
+			importance[j] /= float64(len(inputs))
+		}
+	}
+
+	ranking = make([]int, len(importance))
+	for j := range ranking {
+		ranking[j] = j
+	}
+	sort.SliceStable(ranking, func(a, b int) bool {
+		return importance[ranking[a]] > importance[ranking[b]]
+	})
+	return importance, ranking
+}
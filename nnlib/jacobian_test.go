@@ -0,0 +1,116 @@
+package nnlib
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// finiteDifferenceJacobian estimates dOutput_i/dInput_j with central
+// differences on Predict.
+func finiteDifferenceJacobian(nn *NeuralNetwork, input []float64) [][]float64 {
+	const h = 1e-6
+	jac := make([][]float64, nn.OutputSize())
+	for i := range jac {
+		jac[i] = make([]float64, len(input))
+	}
+	for j := range input {
+		up := append([]float64(nil), input...)
+		down := append([]float64(nil), input...)
+		up[j] += h
+		down[j] -= h
+		outUp, outDown := nn.Predict(up), nn.Predict(down)
+		for i := range jac {
+			jac[i][j] = (outUp[i] - outDown[i]) / (2 * h)
+		}
+	}
+	return jac
+}
+
+func TestJacobianMatchesFiniteDifferences(t *testing.T) {
+	SetSeed(3)
+	for _, output := range []ActivationFunc{Linear{}, &Softmax{}} {
+		nn := NewNeuralNetwork([]int{3, 4, 2}, []ActivationFunc{Tanh{}, output})
+		input := []float64{0.4, -0.9, 0.2}
+		got := nn.Jacobian(input)
+		want := finiteDifferenceJacobian(nn, input)
+		for i := range want {
+			for j := range want[i] {
+				if !approx(got[i][j], want[i][j], 1e-6) {
+					t.Errorf("%T output: J[%d][%d] = %v, want %v", output, i, j, got[i][j], want[i][j])
+				}
+			}
+		}
+		if k := nn.InputGradient(input, 1); !approx(k[2], got[1][2], 1e-12) {
+			t.Errorf("%T output: InputGradient disagrees with Jacobian row", output)
+		}
+	}
+}
+
+func TestIntegratedGradientsLinear(t *testing.T) {
+	nn := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{Linear{}})
+	nn.Layers[0].Weights = [][]float64{{1, -2, 0.5}, {3, 1, -1}}
+	nn.Layers[0].Biases = []float64{0.1, -0.2}
+	input := []float64{2, 1, -1}
+	baseline := []float64{0.5, 0, 1}
+
+	// The top output at input is output 1 (3·2 + 1 + 1 - 0.2 = 7.8).
+	got := nn.IntegratedGradients(input, baseline, 10)
+	total := 0.0
+	for j, w := range nn.Layers[0].Weights[1] {
+		want := w * (input[j] - baseline[j])
+		if !approx(got[j], want, 1e-12) {
+			t.Errorf("attribution %d = %v, want %v", j, got[j], want)
+		}
+		total += got[j]
+	}
+	if change := nn.Predict(input)[1] - nn.Predict(baseline)[1]; !approx(total, change, 1e-12) {
+		t.Errorf("attributions sum to %v, want the output change %v", total, change)
+	}
+}
+
+func TestFGSM(t *testing.T) {
+	SetSeed(6)
+	nn := NewMLP(4, []int{5}, 3, Tanh{}, &Softmax{})
+	input := []float64{0.3, -0.8, 0.5, 0.1}
+	target := []float64{0, 1, 0}
+	loss := func(x []float64) float64 {
+		l, _ := CrossEntropyLoss(nn.Predict(x), target)
+		return l
+	}
+
+	const eps = 0.05
+	adversarial := nn.FGSM(input, target, eps, CrossEntropyLoss)
+	const h = 1e-6
+	for j := range input {
+		up := append([]float64(nil), input...)
+		down := append([]float64(nil), input...)
+		up[j] += h
+		down[j] -= h
+		slope := loss(up) - loss(down)
+		step := adversarial[j] - input[j]
+		if !approx(math.Abs(step), eps, 1e-15) || step*slope <= 0 {
+			t.Errorf("feature %d moved by %v, want ±%v in the direction of the loss slope %v", j, step, eps, slope)
+		}
+	}
+	if before, after := loss(input), loss(adversarial); after <= before {
+		t.Errorf("loss on the adversarial input = %v, want above %v", after, before)
+	}
+}
+
+func TestFeatureImportanceLinear(t *testing.T) {
+	nn := NewNeuralNetwork([]int{4, 1}, []ActivationFunc{Linear{}})
+	nn.Layers[0].Weights = [][]float64{{0.5, -3, 1, 0.1}}
+	nn.Layers[0].Biases = []float64{2}
+	states := [][]float64{{1, 0, 0, 0}, {0, 1, 1, 0}, {0.2, -0.4, 3, 5}}
+
+	importance, ranking := nn.FeatureImportance(states, 0)
+	for j, w := range nn.Layers[0].Weights[0] {
+		if !approx(importance[j], math.Abs(w), 1e-12) {
+			t.Errorf("importance[%d] = %v, want |w| = %v", j, importance[j], math.Abs(w))
+		}
+	}
+	if want := []int{1, 2, 0, 3}; !reflect.DeepEqual(ranking, want) {
+		t.Errorf("ranking = %v, want %v", ranking, want)
+	}
+}
@@ -1,89 +1,155 @@
 package nnlib
 
-import (
-	"math/rand"
-	"time"
-)
-
 // Layer represents a fully connected NN layer
 type Layer struct {
 	Weights    [][]float64
 	Biases     []float64
 	Activation ActivationFunc
+	Init       InitScheme // scheme the weights were drawn from; nil for loaded layers
+
+	// Set by NeuralNetwork.Quantize: Weights ≈ QuantizedWeights * QuantScale
+	QuantizedWeights [][]int8
+	QuantScale       float64
+
+	// SpectralNorm divides Weights by their spectral norm in the forward pass;
+	// Weights stay the trainable parameters. The norm is refreshed with one
+	// power iteration step per Forward.
+	SpectralNorm bool
+	spectralU    []float64
+	sigma        float64
 
-	inputs  []float64
-	outputs []float64
-	deltas  []float64
+	inputs         []float64
+	preActivations []float64
+	outputs        []float64
+	deltas         []float64
 }
 
 // NewLayer initializes a new fully connected layer
 func NewLayer(inputSize, outputSize int, activation ActivationFunc) *Layer {
-	rand.Seed(time.Now().UnixNano())
-	w := make([][]float64, outputSize)
-	for i := range w {
-		w[i] = make([]float64, inputSize)
-		for j := range w[i] {
-			w[i][j] = rand.Float64()*0.2 - 0.1
-		}
+	return NewLayerWithInit(inputSize, outputSize, activation, UniformInit{Limit: 0.1}, ZeroBias{})
+}
+
+// NewLayerWithInit initializes a new fully connected layer using the given
+// weight and bias init schemes; a nil biasInit gives zero biases
+func NewLayerWithInit(inputSize, outputSize int, activation ActivationFunc, init InitScheme, biasInit BiasInit) *Layer {
+	if biasInit == nil {
+		biasInit = ZeroBias{}
 	}
-	b := make([]float64, outputSize)
 	return &Layer{
-		Weights:    w,
-		Biases:     b,
+		Weights:    init.InitWeights(outputSize, inputSize),
+		Biases:     biasInit.InitBiases(inputSize, outputSize),
 		Activation: activation,
+		Init:       init,
 	}
 }
 
+// NewSpectralNormLayer initializes a fully connected layer whose forward
+// weights are spectrally normalized
+func NewSpectralNormLayer(inputSize, outputSize int, activation ActivationFunc) *Layer {
+	l := NewLayer(inputSize, outputSize, activation)
+	l.SpectralNorm = true
+	return l
+}
+
 // Forward propagates input through layer
 func (l *Layer) Forward(input []float64) []float64 {
 	l.inputs = input
-	output := make([]float64, len(l.Weights))
+	l.updateSpectralNorm()
+	l.preActivations, l.outputs = l.compute(input)
+	return l.outputs
+}
 
+// compute returns the weighted sums and the layer output without touching
+// the training caches
+func (l *Layer) compute(input []float64) (preActivations, output []float64) {
+	scale := l.weightScale()
+	preActivations = make([]float64, len(l.Weights))
 	for i := range l.Weights {
-		sum := l.Biases[i]
+		sum := 0.0
 		for j := range input {
 			sum += l.Weights[i][j] * input[j]
 		}
+		preActivations[i] = l.Biases[i] + scale*sum
+	}
+	return preActivations, l.activate(preActivations)
+}
+
+// activate applies the layer activation to the weighted sums
+func (l *Layer) activate(sums []float64) []float64 {
+	output := make([]float64, len(sums))
+	for i, sum := range sums {
 		output[i] = l.Activation.Activate(sum)
 	}
 
 	// Special case for Softmax activation applied to entire output vector
 	if softmax, ok := l.Activation.(*Softmax); ok {
-		output = softmax.ActivateVector(output)
+		return softmax.ActivateVector(output)
 	}
-
-	l.outputs = output
 	return output
 }
 
+// inputGradient maps a gradient with respect to the layer output back to its
+// input, given the pre-activations and output of a forward pass. Unlike
+// Backward it uses the full softmax Jacobian rather than assuming a fused
+// cross-entropy loss, and it touches neither the caches nor the weights.
+func (l *Layer) inputGradient(preActivations, output, outputGrad []float64) []float64 {
+	var deltas []float64
+	if _, ok := l.Activation.(*Softmax); ok {
+		deltas = softmaxBackward(output, outputGrad)
+	} else {
+		deltas = make([]float64, len(output))
+		for k := range output {
+			deltas[k] = outputGrad[k] * l.Activation.Derivative(preActivations[k])
+		}
+	}
+
+	return l.deltasToInput(deltas)
+}
+
+// deltasToInput maps a gradient with respect to the pre-activations back to
+// the layer input
+func (l *Layer) deltasToInput(deltas []float64) []float64 {
+	scale := l.weightScale()
+	inputGrad := make([]float64, len(l.Weights[0]))
+	for k, d := range deltas {
+		for j := range inputGrad {
+			inputGrad[j] += scale * d * l.Weights[k][j]
+		}
+	}
+	return inputGrad
+}
+
 // Backward propagates error, updates weights if learningRate > 0
 func (l *Layer) Backward(errorGrad []float64, learningRate float64) []float64 {
 	l.deltas = make([]float64, len(l.outputs))
 
 	// For softmax + cross-entropy, derivative simplified
-	if _, ok := l.Activation.(*Softmax); ok {
-		for i := range l.outputs {
-			l.deltas[i] = errorGrad[i]
+	if softmax, ok := l.Activation.(*Softmax); ok {
+		if softmax.FullJacobian {
+			l.deltas = softmaxBackward(l.outputs, errorGrad)
+		} else {
+			copy(l.deltas, errorGrad)
 		}
 	} else {
 		for i := range l.outputs {
-			l.deltas[i] = errorGrad[i] * l.Activation.Derivative(l.outputs[i])
+			l.deltas[i] = errorGrad[i] * l.Activation.Derivative(l.preActivations[i])
 		}
 	}
 
+	scale := l.weightScale()
 	prevError := make([]float64, len(l.inputs))
 	for j := range l.inputs {
 		sum := 0.0
 		for i := range l.deltas {
 			sum += l.deltas[i] * l.Weights[i][j]
 		}
-		prevError[j] = sum
+		prevError[j] = scale * sum
 	}
 
 	if learningRate > 0 {
 		for i := range l.Weights {
 			for j := range l.Weights[i] {
-				l.Weights[i][j] -= learningRate * l.deltas[i] * l.inputs[j]
+				l.Weights[i][j] -= learningRate * scale * l.deltas[i] * l.inputs[j]
 			}
 			l.Biases[i] -= learningRate * l.deltas[i]
 		}
@@ -91,3 +157,43 @@ func (l *Layer) Backward(errorGrad []float64, learningRate float64) []float64 {
 
 	return prevError
 }
+
+// updateSpectralNorm refreshes the spectral norm estimate by one power
+// iteration step, or runs to convergence the first time
+func (l *Layer) updateSpectralNorm() {
+	if !l.SpectralNorm {
+		return
+	}
+	if l.spectralU == nil {
+		l.spectralU, l.sigma = powerIterate(l.Weights, spectralStart(len(l.Weights)), spectralIterations)
+		return
+	}
+	l.spectralU, l.sigma = powerStep(l.Weights, l.spectralU)
+}
+
+// weightScale is the factor applied to Weights in the forward pass: 1/σ for
+// spectrally normalized layers, 1 otherwise. σ is treated as a constant when
+// backpropagating.
+func (l *Layer) weightScale() float64 {
+	if !l.SpectralNorm {
+		return 1
+	}
+	if l.spectralU == nil {
+		l.updateSpectralNorm()
+	}
+	if l.sigma == 0 {
+		return 1
+	}
+	return 1 / l.sigma
+}
+
+// EffectiveWeights returns the weight matrix used in the forward pass, which
+// differs from Weights for spectrally normalized layers
+func (l *Layer) EffectiveWeights() [][]float64 {
+	scale := l.weightScale()
+	weights := make([][]float64, len(l.Weights))
+	for i, row := range l.Weights {
+		weights[i] = ScalarMultiply(row, scale)
+	}
+	return weights
+}
@@ -0,0 +1,93 @@
+package nnlib
+
+import (
+	"math"
+	"testing"
+)
+
+// TestBackwardMatchesFiniteDifferences checks the input gradient Backward
+// returns for d(Σ outputs)/d(input) against central differences.
+func TestBackwardMatchesFiniteDifferences(t *testing.T) {
+	for _, act := range []ActivationFunc{Sigmoid{}, Tanh{}, Swish{}} {
+		layer := &Layer{
+			Weights:    [][]float64{{0.5, -1.2, 0.3}, {0.8, 0.1, -0.6}},
+			Biases:     []float64{0.2, -0.4},
+			Activation: act,
+		}
+		input := []float64{0.7, -0.3, 1.1}
+
+		layer.Forward(input)
+		grad := layer.Backward([]float64{1, 1}, 0)
+
+		const h = 1e-6
+		for j := range input {
+			up := append([]float64(nil), input...)
+			down := append([]float64(nil), input...)
+			up[j] += h
+			down[j] -= h
+			want := (Sum(layer.Forward(up)) - Sum(layer.Forward(down))) / (2 * h)
+			if math.Abs(grad[j]-want) > 1e-6 {
+				t.Errorf("%T: dInput[%d] = %v, want %v", act, j, grad[j], want)
+			}
+		}
+	}
+}
+
+// sumSquaresLoss is Σ(p - t)², whose gradient 2(p - t) is exact, so the
+// backward pass can be checked against differences of the loss
+func sumSquaresLoss(predicted, target []float64) (loss float64, grad []float64) {
+	grad = make([]float64, len(predicted))
+	for i := range predicted {
+		diff := predicted[i] - target[i]
+		loss += diff * diff
+		grad[i] = 2 * diff
+	}
+	return loss, grad
+}
+
+// TestSoftmaxFullJacobianBackward checks the weight gradients of a softmax
+// output with FullJacobian under a non-cross-entropy loss against central
+// differences of the loss.
+func TestSoftmaxFullJacobianBackward(t *testing.T) {
+	SetSeed(3)
+	nn := NewMLP(3, []int{4}, 3, Tanh{}, &Softmax{FullJacobian: true})
+	inputs := [][]float64{{0.4, -0.7, 1.2}, {-0.3, 0.9, 0.1}}
+	targets := [][]float64{{0.2, 0.5, 0.3}, {1, 0, 0}}
+	meanLoss := func() float64 {
+		total := 0.0
+		for i, x := range inputs {
+			l, _ := sumSquaresLoss(nn.Predict(x), targets[i])
+			total += l
+		}
+		return total / float64(len(inputs))
+	}
+
+	_, weightGrads, biasGrads := nn.batchGradients(inputs, targets, sumSquaresLoss)
+	const h = 1e-6
+	numeric := func(p *float64) float64 {
+		orig := *p
+		*p = orig + h
+		up := meanLoss()
+		*p = orig - h
+		down := meanLoss()
+		*p = orig
+		return (up - down) / (2 * h)
+	}
+	for l, layer := range nn.Layers {
+		for j, row := range layer.Weights {
+			for k := range row {
+				if want := numeric(&row[k]); !approx(weightGrads[l][j][k], want, 1e-8) {
+					t.Errorf("dW[%d][%d][%d] = %v, want %v", l, j, k, weightGrads[l][j][k], want)
+				}
+			}
+			if want := numeric(&layer.Biases[j]); !approx(biasGrads[l][j], want, 1e-8) {
+				t.Errorf("dB[%d][%d] = %v, want %v", l, j, biasGrads[l][j], want)
+			}
+		}
+	}
+
+	jac := SoftmaxJacobian([]float64{0.2, 0.5, 0.3})
+	if !approx(jac[0][0], 0.16, 1e-15) || !approx(jac[0][1], -0.1, 1e-15) || !approx(jac[2][1], -0.15, 1e-15) {
+		t.Errorf("SoftmaxJacobian = %v", jac)
+	}
+}
@@ -0,0 +1,33 @@
+package nnlib
+
+// lineSearchRates are the candidate step sizes tried by StepWithLineSearch,
+// from most to least aggressive.
+var lineSearchRates = []float64{1, 0.5, 0.25, 0.1, 0.05, 0.01, 0.005, 0.001}
+
+// StepWithLineSearch takes one cross-entropy gradient step on a single example,
+// trying each candidate rate along the negative gradient and keeping the one
+// with the lowest resulting loss. Candidates are scored with plain SGD steps
+// so optimizer noise can't pick the rate; the chosen step then goes through
+// the optimizer and the usual training hooks like TrainBatch. It returns the
+// chosen rate, or 0 (with the weights unchanged) if no candidate reduces the
+// loss, and the error of a rolled-back update (see CheckFinite).
+func (nn *NeuralNetwork) StepWithLineSearch(input, target []float64) (float64, error) {
+	inputs, targets := [][]float64{input}, [][]float64{target}
+	baseLoss, weightGrads, biasGrads := nn.batchGradients(inputs, targets, CrossEntropyLoss)
+	weights, biases := nn.snapshot()
+
+	bestRate, bestLoss := 0.0, baseLoss
+	for _, rate := range lineSearchRates {
+		nn.applyGradientsWith(SGD{}, weightGrads, biasGrads, rate)
+		loss, _ := CrossEntropyLoss(nn.Forward(input), target)
+		if loss < bestLoss {
+			bestRate, bestLoss = rate, loss
+		}
+		nn.restore(weights, biases)
+	}
+
+	if bestRate == 0 {
+		return 0, nil
+	}
+	return bestRate, nn.step(weightGrads, biasGrads, bestRate)
+}
@@ -0,0 +1,55 @@
+package nnlib
+
+import "testing"
+
+func TestStepWithLineSearchReducesLoss(t *testing.T) {
+	// A single softmax layer under cross-entropy is convex in its weights.
+	nn := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{&Softmax{}})
+	input, target := []float64{0.5, -1, 2}, []float64{0, 1}
+
+	for i := 0; i < 5; i++ {
+		before, _ := CrossEntropyLoss(nn.Predict(input), target)
+		rate, err := nn.StepWithLineSearch(input, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		after, _ := CrossEntropyLoss(nn.Predict(input), target)
+		if after > before {
+			t.Errorf("step %d with rate %v raised the loss from %v to %v", i, rate, before, after)
+		}
+	}
+}
+
+func TestStepWithLineSearchIgnoresOptimizerNoise(t *testing.T) {
+	input, target := []float64{0.5, -1, 2}, []float64{0, 1}
+	for seed := int64(1); seed <= 10; seed++ {
+		SetSeed(seed)
+		plain := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{&Softmax{}})
+		SetSeed(seed)
+		noisy := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{&Softmax{}})
+		noisy.Optimizer = &SGLD{NoiseScale: 100}
+
+		want, _ := plain.StepWithLineSearch(input, target)
+		if got, _ := noisy.StepWithLineSearch(input, target); got != want {
+			t.Errorf("seed %d: rate with a noisy SGLD optimizer = %v, want the noise-free choice %v", seed, got, want)
+		}
+	}
+}
+
+func TestStepWithLineSearchUsesTrainingStep(t *testing.T) {
+	SetSeed(4)
+	nn := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{&Softmax{}})
+	calls := 0
+	nn.OnGradient(func(float64) { calls++ })
+
+	rate, err := nn.StepWithLineSearch([]float64{0.5, -1, 2}, []float64{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate == 0 {
+		t.Fatal("no candidate rate reduced the loss")
+	}
+	if calls != 1 || nn.updates != 1 {
+		t.Errorf("gradient hook ran %d times and %d updates were counted, want 1 each", calls, nn.updates)
+	}
+}
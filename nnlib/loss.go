@@ -2,6 +2,10 @@ package nnlib
 
 import "math"
 
+// LossFunc is the signature shared by the loss functions in this file: it
+// returns the loss and its gradient with respect to the network output.
+type LossFunc func(predicted, target []float64) (loss float64, grad []float64)
+
 // CrossEntropyLoss computes the cross-entropy loss and its gradient.
 // predicted: output probabilities (after softmax), target: one-hot encoded labels.
 func CrossEntropyLoss(predicted, target []float64) (loss float64, grad []float64) {
@@ -26,11 +30,99 @@ func CrossEntropyLoss(predicted, target []float64) (loss float64, grad []float64
 // predicted: predicted values, target: true values.
 func MSELoss(predicted, target []float64) (loss float64, grad []float64) {
 	grad = make([]float64, len(predicted))
+	n := float64(len(predicted))
 	for i := range predicted {
 		diff := predicted[i] - target[i]
 		loss += diff * diff
-		grad[i] = 2 * diff
+		grad[i] = 2 * diff / n
+	}
+	return loss / n, grad
+}
+
+// HuberLoss returns the Huber loss with threshold delta: 0.5·d² for errors
+// |d| ≤ delta and delta·(|d| - 0.5·delta) beyond, averaged over outputs like
+// MSELoss. Its gradient is d clipped to [-delta, delta] (divided by the
+// number of outputs to match the average), so outlier targets (e.g. large TD
+// errors on Bellman targets) can't produce huge updates.
+func HuberLoss(delta float64) LossFunc {
+	if delta <= 0 {
+		panic("HuberLoss: delta must be positive")
+	}
+	return func(predicted, target []float64) (loss float64, grad []float64) {
+		grad = make([]float64, len(predicted))
+		for i := range predicted {
+			diff := predicted[i] - target[i]
+			if math.Abs(diff) <= delta {
+				loss += 0.5 * diff * diff
+				grad[i] = diff
+			} else {
+				loss += delta * (math.Abs(diff) - 0.5*delta)
+				grad[i] = math.Copysign(delta, diff)
+			}
+		}
+		n := float64(len(predicted))
+		for i := range grad {
+			grad[i] /= n
+		}
+		return loss / n, grad
+	}
+}
+
+// LossTerm applies Fn to the output slice [Start, End) scaled by Weight.
+type LossTerm struct {
+	Fn         LossFunc
+	Start, End int
+	Weight     float64
+}
+
+// CompositeLoss combines per-head losses for multi-task networks. Each term
+// sees only its slice of the output and target; the combined loss is the
+// weighted sum and the gradient is the weighted per-head gradients placed at
+// their output positions (zero where no term applies).
+type CompositeLoss struct {
+	Terms []LossTerm
+}
+
+// Loss has the LossFunc signature, so c.Loss can be passed to Fit or
+// TrainWithLoss.
+func (c CompositeLoss) Loss(predicted, target []float64) (loss float64, grad []float64) {
+	grad = make([]float64, len(predicted))
+	for _, term := range c.Terms {
+		l, g := term.Fn(predicted[term.Start:term.End], target[term.Start:term.End])
+		loss += term.Weight * l
+		for i, v := range g {
+			grad[term.Start+i] += term.Weight * v
+		}
 	}
-	loss /= float64(len(predicted))
 	return loss, grad
 }
+
+// MaskedLoss wraps loss so that output positions with mask 0 (e.g. sequence
+// padding) contribute nothing: loss only sees the unmasked positions, so an
+// averaging loss like MSELoss is averaged over the real outputs rather than
+// diluted by the padding. Each gradient entry is scaled by its mask and is 0
+// at masked positions. A fully masked sample has loss 0.
+func MaskedLoss(loss LossFunc, mask []float64) LossFunc {
+	return func(predicted, target []float64) (float64, []float64) {
+		var keep []int
+		for i := range predicted {
+			if mask[i] != 0 {
+				keep = append(keep, i)
+			}
+		}
+		grad := make([]float64, len(predicted))
+		if len(keep) == 0 {
+			return 0, grad
+		}
+		p := make([]float64, len(keep))
+		t := make([]float64, len(keep))
+		for k, i := range keep {
+			p[k], t[k] = predicted[i], target[i]
+		}
+		l, g := loss(p, t)
+		for k, i := range keep {
+			grad[i] = g[k] * mask[i]
+		}
+		return l, grad
+	}
+}
@@ -0,0 +1,131 @@
+package nnlib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompositeLoss(t *testing.T) {
+	c := CompositeLoss{Terms: []LossTerm{
+		{Fn: CrossEntropyLoss, Start: 0, End: 2, Weight: 0.7},
+		{Fn: MSELoss, Start: 2, End: 4, Weight: 0.3},
+	}}
+	predicted := []float64{0.8, 0.2, 1.5, -0.5}
+	target := []float64{1, 0, 1, 0}
+
+	ceLoss, ceGrad := CrossEntropyLoss(predicted[:2], target[:2])
+	mseLoss, mseGrad := MSELoss(predicted[2:], target[2:])
+	loss, grad := c.Loss(predicted, target)
+
+	if want := 0.7*ceLoss + 0.3*mseLoss; !approx(loss, want, 1e-12) {
+		t.Errorf("loss = %v, want %v", loss, want)
+	}
+	want := []float64{0.7 * ceGrad[0], 0.7 * ceGrad[1], 0.3 * mseGrad[0], 0.3 * mseGrad[1]}
+	for i := range want {
+		if !approx(grad[i], want[i], 1e-12) {
+			t.Errorf("grad = %v, want %v", grad, want)
+			break
+		}
+	}
+
+	nn := NewNeuralNetwork([]int{2, 4}, []ActivationFunc{Linear{}})
+	before := nn.Predict([]float64{1, 1})
+	if err := nn.TrainWithLoss([]float64{1, 1}, target, 0.1, c.Loss); err != nil {
+		t.Fatal(err)
+	}
+	if after := nn.Predict([]float64{1, 1}); after[3] == before[3] {
+		t.Error("training with CompositeLoss left the regression head unchanged")
+	}
+}
+
+func TestMaskedPaddingContributesNoGradient(t *testing.T) {
+	inputs := [][]float64{{1, 0.5}, {-0.3, 2}}
+	targets := [][]float64{{1, 2, 0}, {0.5, 0, 0}}
+	masks := [][]float64{{1, 1, 0}, {1, 0, 0}}
+
+	nn := NewNeuralNetwork([]int{2, 3}, []ActivationFunc{Linear{}})
+	loss, weightGrads, biasGrads := nn.maskedBatchGradients(inputs, targets, masks, MSELoss)
+
+	// Output 2 is padding in every sample, so its whole row gets no gradient.
+	if weightGrads[0][2][0] != 0 || weightGrads[0][2][1] != 0 || biasGrads[0][2] != 0 {
+		t.Errorf("padded output gradients = %v, %v, want zero", weightGrads[0][2], biasGrads[0][2])
+	}
+	if biasGrads[0][0] == 0 || biasGrads[0][1] == 0 {
+		t.Errorf("unpadded output gradients = %v, want non-zero", biasGrads[0][:2])
+	}
+
+	// Changing a padded target must change neither loss nor gradients.
+	targets[1][1] = 100
+	targets[0][2] = -100
+	loss2, weightGrads2, biasGrads2 := nn.maskedBatchGradients(inputs, targets, masks, MSELoss)
+	if loss2 != loss || !reflect.DeepEqual(weightGrads2, weightGrads) || !reflect.DeepEqual(biasGrads2, biasGrads) {
+		t.Error("padded targets affected the loss or gradients")
+	}
+
+	before := append([]float64(nil), nn.Layers[0].Weights[2]...)
+	if _, err := nn.TrainBatchMasked(inputs, targets, masks, 0.1, MSELoss); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(nn.Layers[0].Weights[2], before) {
+		t.Error("TrainBatchMasked updated the weights of a padded output")
+	}
+}
+
+func TestMaskedLossAveragesUnmaskedPositions(t *testing.T) {
+	predicted := []float64{1, 3, 7, 9}
+	target := []float64{2, 1, 0, 0}
+	mask := []float64{1, 1, 0, 0}
+
+	loss, grad := MaskedLoss(MSELoss, mask)(predicted, target)
+	wantLoss, wantGrad := MSELoss(predicted[:2], target[:2])
+	if loss != wantLoss {
+		t.Errorf("loss = %v, want %v (the mean over the 2 unpadded outputs)", loss, wantLoss)
+	}
+	if !reflect.DeepEqual(grad, append(wantGrad, 0, 0)) {
+		t.Errorf("grad = %v, want %v followed by zeros", grad, wantGrad)
+	}
+
+	loss, grad = MaskedLoss(MSELoss, []float64{0, 0, 0, 0})(predicted, target)
+	if loss != 0 || !reflect.DeepEqual(grad, []float64{0, 0, 0, 0}) {
+		t.Errorf("fully masked: loss %v, grad %v, want 0 and zeros", loss, grad)
+	}
+}
+
+func TestHuberLossGradient(t *testing.T) {
+	huber := HuberLoss(1)
+	predicted := []float64{0.3, 4, -2.5, 1}
+	target := []float64{0, 0, 0, 1.2}
+	loss, grad := huber(predicted, target)
+	// 0.5·0.09, 1·(4-0.5), 1·(2.5-0.5), 0.5·0.04, averaged.
+	if want := (0.045 + 3.5 + 2 + 0.02) / 4; !approx(loss, want, 1e-12) {
+		t.Errorf("loss = %v, want %v", loss, want)
+	}
+	const h = 1e-6
+	for i := range predicted {
+		up := append([]float64(nil), predicted...)
+		down := append([]float64(nil), predicted...)
+		up[i] += h
+		down[i] -= h
+		lu, _ := huber(up, target)
+		ld, _ := huber(down, target)
+		if want := (lu - ld) / (2 * h); !approx(grad[i], want, 1e-6) {
+			t.Errorf("grad[%d] = %v, want %v", i, grad[i], want)
+		}
+	}
+	expectPanic(t, func() { HuberLoss(0) })
+}
+
+func TestMSELossGradient(t *testing.T) {
+	predicted := []float64{0.5, -1, 2}
+	target := []float64{1, 1, 1}
+	loss, grad := MSELoss(predicted, target)
+	if want := (0.25 + 4 + 1) / 3; !approx(loss, want, 1e-12) {
+		t.Errorf("loss = %v, want %v", loss, want)
+	}
+	// d/dp of mean((p - t)²) is 2(p - t)/n.
+	for i, want := range []float64{-1.0 / 3, -4.0 / 3, 2.0 / 3} {
+		if !approx(grad[i], want, 1e-12) {
+			t.Errorf("grad[%d] = %v, want %v", i, grad[i], want)
+		}
+	}
+}
@@ -2,13 +2,51 @@ package nnlib
 
 import (
 	"fmt"
+	"math"
 )
 
 // NeuralNetwork holds layers of the model
 type NeuralNetwork struct {
-	Layers []*Layer
+	Layers    []*Layer
+	Optimizer Optimizer // nil means plain SGD
+
+	// OutputType is OutputLogits or OutputProbs. When empty it is derived
+	// from whether the final layer is Softmax.
+	OutputType string
+
+	// CheckFinite scans the parameters after every training update and, if
+	// any became NaN or Inf, restores the pre-update values and returns an
+	// error from the training call.
+	CheckFinite bool
+
+	// GradientNoiseStddev adds N(0, σ_t²) noise to every gradient before it
+	// is applied, with σ_t = GradientNoiseStddev / (1+t)^(GradientNoiseDecay/2)
+	// after t updates (decay 0.55 is the usual choice). Noise is drawn from
+	// the package RNG, so SetSeed makes it reproducible.
+	GradientNoiseStddev float64
+	GradientNoiseDecay  float64
+
+	// LossScale, when positive, multiplies the loss gradient before
+	// backpropagation and divides the accumulated gradients by the same
+	// factor before they are used, so tiny gradients don't underflow in the
+	// backward pass while the applied update stays the same.
+	LossScale float64
+
+	onGradient func(norm float64)
+	updates    int
+
+	// Polyak averaging state, see EnablePolyakAveraging
+	polyakDecay   float64
+	shadowWeights [][][]float64
+	shadowBiases  [][]float64
 }
 
+// Values of NeuralNetwork.OutputType
+const (
+	OutputLogits = "logits"
+	OutputProbs  = "probs"
+)
+
 // NewNeuralNetwork creates a NN from layer sizes and activations
 func NewNeuralNetwork(sizes []int, activations []ActivationFunc) *NeuralNetwork {
 	if len(sizes)-1 != len(activations) {
@@ -21,27 +59,258 @@ func NewNeuralNetwork(sizes []int, activations []ActivationFunc) *NeuralNetwork
 	return nn
 }
 
+// NewMLP creates a multilayer perceptron with the given hidden layer sizes,
+// all using the hidden activation, and an output layer using output
+func NewMLP(inputSize int, hiddenSizes []int, outputSize int, hidden, output ActivationFunc) *NeuralNetwork {
+	sizes := append(append([]int{inputSize}, hiddenSizes...), outputSize)
+	activations := make([]ActivationFunc, len(sizes)-1)
+	for i := range activations {
+		activations[i] = hidden
+	}
+	activations[len(activations)-1] = output
+	return NewNeuralNetwork(sizes, activations)
+}
+
 // Forward propagates input through all layers
 func (nn *NeuralNetwork) Forward(input []float64) []float64 {
+	nn.checkInput(input)
 	for _, layer := range nn.Layers {
 		input = layer.Forward(input)
 	}
 	return input
 }
 
-// Train on one example with cross-entropy loss by default
-func (nn *NeuralNetwork) Train(input, target []float64, learningRate float64) {
-	output := nn.Forward(input)
-	_, grad := CrossEntropyLoss(output, target)
-	errorGrad := grad
+// InputSize returns the number of inputs the first layer expects
+func (nn *NeuralNetwork) InputSize() int {
+	if len(nn.Layers) == 0 || len(nn.Layers[0].Weights) == 0 {
+		return 0
+	}
+	return len(nn.Layers[0].Weights[0])
+}
+
+// OutputSize returns the number of outputs of the final layer
+func (nn *NeuralNetwork) OutputSize() int {
+	if len(nn.Layers) == 0 {
+		return 0
+	}
+	return len(nn.Layers[len(nn.Layers)-1].Weights)
+}
 
-	for i := len(nn.Layers) - 1; i >= 0; i-- {
-		errorGrad = nn.Layers[i].Backward(errorGrad, learningRate)
+func (nn *NeuralNetwork) checkInput(input []float64) {
+	if len(nn.Layers) > 0 && len(input) != nn.InputSize() {
+		panic(fmt.Sprintf("input has %d values but the network expects %d", len(input), nn.InputSize()))
 	}
 }
 
+func (nn *NeuralNetwork) checkTarget(target []float64) {
+	if len(target) != nn.OutputSize() {
+		panic(fmt.Sprintf("target has %d values but the network outputs %d", len(target), nn.OutputSize()))
+	}
+}
+
+// ValidateTopology checks that every layer has a rectangular weight matrix
+// with one bias per row and that each layer's input size matches the previous
+// layer's output size. The first problem found is returned wrapping
+// ErrShapeMismatch.
+func (nn *NeuralNetwork) ValidateTopology() error {
+	for i, layer := range nn.Layers {
+		if len(layer.Weights) == 0 || len(layer.Weights[0]) == 0 {
+			return fmt.Errorf("ValidateTopology: %w: layer %d has no weights", ErrShapeMismatch, i)
+		}
+		in := len(layer.Weights[0])
+		for r, row := range layer.Weights {
+			if len(row) != in {
+				return fmt.Errorf("ValidateTopology: %w: layer %d row %d has %d weights, want %d", ErrShapeMismatch, i, r, len(row), in)
+			}
+		}
+		if len(layer.Biases) != len(layer.Weights) {
+			return fmt.Errorf("ValidateTopology: %w: layer %d has %d biases for %d outputs", ErrShapeMismatch, i, len(layer.Biases), len(layer.Weights))
+		}
+		if i > 0 {
+			if prev := len(nn.Layers[i-1].Weights); in != prev {
+				return fmt.Errorf("ValidateTopology: %w: layer %d takes %d inputs but layer %d outputs %d", ErrShapeMismatch, i, in, i-1, prev)
+			}
+		}
+	}
+	return nil
+}
+
+// Train on one example with cross-entropy loss by default
+func (nn *NeuralNetwork) Train(input, target []float64, learningRate float64) error {
+	_, err := nn.trainBatch([][]float64{input}, [][]float64{target}, learningRate, CrossEntropyLoss)
+	return err
+}
+
+// TrainWithLoss trains on one example under the given loss, e.g. MSELoss or
+// a CompositeLoss's Loss method
+func (nn *NeuralNetwork) TrainWithLoss(input, target []float64, learningRate float64, loss LossFunc) error {
+	_, err := nn.trainBatch([][]float64{input}, [][]float64{target}, learningRate, loss)
+	return err
+}
+
 // TrainBatch processes batch of samples, averages gradients
-func (nn *NeuralNetwork) TrainBatch(inputs, targets [][]float64, learningRate float64) {
+func (nn *NeuralNetwork) TrainBatch(inputs, targets [][]float64, learningRate float64) error {
+	_, err := nn.trainBatch(inputs, targets, learningRate, CrossEntropyLoss)
+	return err
+}
+
+// trainBatch runs one averaged gradient step under the given loss and
+// returns the mean loss over the batch before the update.
+func (nn *NeuralNetwork) trainBatch(inputs, targets [][]float64, learningRate float64, loss LossFunc) (float64, error) {
+	batchLoss, weightGrads, biasGrads := nn.batchGradients(inputs, targets, loss)
+	return batchLoss, nn.step(weightGrads, biasGrads, learningRate)
+}
+
+// step is the single update path shared by the training methods: it reports
+// the gradient norm, applies the gradients and, with CheckFinite, rolls back
+// an update that produced NaN or Inf parameters. Accepted updates feed the
+// Polyak average.
+func (nn *NeuralNetwork) step(weightGrads [][][]float64, biasGrads [][]float64, learningRate float64) error {
+	if nn.onGradient != nil {
+		nn.onGradient(gradientNorm(weightGrads, biasGrads))
+	}
+	weightGrads, biasGrads = nn.addGradientNoise(weightGrads, biasGrads)
+	nn.updates++
+	if nn.CheckFinite {
+		weights, biases := nn.snapshot()
+		nn.applyGradients(weightGrads, biasGrads, learningRate)
+		if layer, ok := nn.firstNonFinite(); ok {
+			nn.restore(weights, biases)
+			return fmt.Errorf("update produced non-finite parameters in layer %d; rolled back", layer)
+		}
+	} else {
+		nn.applyGradients(weightGrads, biasGrads, learningRate)
+	}
+	nn.updatePolyak()
+	return nil
+}
+
+// CurrentGradientNoise returns the gradient noise standard deviation for the
+// next update under the decay schedule
+func (nn *NeuralNetwork) CurrentGradientNoise() float64 {
+	return nn.GradientNoiseStddev / math.Pow(1+float64(nn.updates), nn.GradientNoiseDecay/2)
+}
+
+// addGradientNoise returns copies of the gradients perturbed with the
+// scheduled Gaussian noise, leaving the caller's slices untouched. Without
+// noise the gradients are returned as they are.
+func (nn *NeuralNetwork) addGradientNoise(weightGrads [][][]float64, biasGrads [][]float64) ([][][]float64, [][]float64) {
+	stddev := nn.CurrentGradientNoise()
+	if stddev == 0 {
+		return weightGrads, biasGrads
+	}
+	noisyWeights := make([][][]float64, len(weightGrads))
+	noisyBiases := make([][]float64, len(biasGrads))
+	for i := range weightGrads {
+		noisyWeights[i] = make([][]float64, len(weightGrads[i]))
+		for j, row := range weightGrads[i] {
+			noisyWeights[i][j] = make([]float64, len(row))
+			for k, g := range row {
+				noisyWeights[i][j][k] = g + stddev*rng.NormFloat64()
+			}
+		}
+		noisyBiases[i] = make([]float64, len(biasGrads[i]))
+		for j, g := range biasGrads[i] {
+			noisyBiases[i][j] = g + stddev*rng.NormFloat64()
+		}
+	}
+	return noisyWeights, noisyBiases
+}
+
+// firstNonFinite returns the index of the first layer holding a NaN or Inf
+// weight or bias
+func (nn *NeuralNetwork) firstNonFinite() (int, bool) {
+	for i, layer := range nn.Layers {
+		for _, row := range layer.Weights {
+			for _, w := range row {
+				if math.IsNaN(w) || math.IsInf(w, 0) {
+					return i, true
+				}
+			}
+		}
+		for _, b := range layer.Biases {
+			if math.IsNaN(b) || math.IsInf(b, 0) {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// OnGradient registers a hook called on every training step with the global
+// L2 norm of the averaged gradients, before they are applied
+func (nn *NeuralNetwork) OnGradient(fn func(norm float64)) {
+	nn.onGradient = fn
+}
+
+// gradientNorm returns the L2 norm over all weight and bias gradients
+func gradientNorm(weightGrads [][][]float64, biasGrads [][]float64) float64 {
+	sum := 0.0
+	for i := range weightGrads {
+		for _, row := range weightGrads[i] {
+			for _, g := range row {
+				sum += g * g
+			}
+		}
+		for _, g := range biasGrads[i] {
+			sum += g * g
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+// TrainBatchMasked is TrainBatch under the given loss with a per-sample output
+// mask (see MaskedLoss), so zero-padded positions of variable-length targets
+// contribute no loss or gradient. It returns the mean masked loss.
+func (nn *NeuralNetwork) TrainBatchMasked(inputs, targets, masks [][]float64, learningRate float64, loss LossFunc) (float64, error) {
+	if len(masks) != len(inputs) {
+		panic("TrainBatchMasked: need one mask per input")
+	}
+	batchLoss, weightGrads, biasGrads := nn.maskedBatchGradients(inputs, targets, masks, loss)
+	return batchLoss, nn.step(weightGrads, biasGrads, learningRate)
+}
+
+// ComputeBatchGradients backpropagates the batch under cross-entropy loss and
+// returns the weight and bias gradients averaged over it, laid out like the
+// layers' Weights and Biases, without updating any parameters. Gradients
+// aggregated elsewhere (e.g. averaged across workers) can then be applied
+// with ApplyGradients.
+func (nn *NeuralNetwork) ComputeBatchGradients(inputs, targets [][]float64) (weightGrads [][][]float64, biasGrads [][]float64) {
+	_, weightGrads, biasGrads = nn.batchGradients(inputs, targets, CrossEntropyLoss)
+	return weightGrads, biasGrads
+}
+
+// ApplyGradients takes one training step with externally computed gradients
+// shaped like ComputeBatchGradients' results, going through the optimizer,
+// gradient hook, noise and CheckFinite like the training methods.
+// Mismatched shapes are an error wrapping ErrShapeMismatch and leave the
+// network untouched.
+func (nn *NeuralNetwork) ApplyGradients(weightGrads [][][]float64, biasGrads [][]float64, learningRate float64) error {
+	if len(weightGrads) != len(nn.Layers) || len(biasGrads) != len(nn.Layers) {
+		return fmt.Errorf("ApplyGradients: %w: got gradients for %d and %d layers, want %d", ErrShapeMismatch, len(weightGrads), len(biasGrads), len(nn.Layers))
+	}
+	for i, layer := range nn.Layers {
+		if len(weightGrads[i]) != len(layer.Weights) || len(biasGrads[i]) != len(layer.Biases) {
+			return fmt.Errorf("ApplyGradients: %w: layer %d gradients don't match its %d outputs", ErrShapeMismatch, i, len(layer.Weights))
+		}
+		for j, row := range weightGrads[i] {
+			if len(row) != len(layer.Weights[j]) {
+				return fmt.Errorf("ApplyGradients: %w: layer %d row %d has %d gradients, want %d", ErrShapeMismatch, i, j, len(row), len(layer.Weights[j]))
+			}
+		}
+	}
+	return nn.step(weightGrads, biasGrads, learningRate)
+}
+
+// batchGradients backpropagates every sample and returns the mean loss with
+// the weight and bias gradients averaged over the batch. Weights are untouched.
+func (nn *NeuralNetwork) batchGradients(inputs, targets [][]float64, loss LossFunc) (float64, [][][]float64, [][]float64) {
+	return nn.maskedBatchGradients(inputs, targets, nil, loss)
+}
+
+// maskedBatchGradients is batchGradients with an optional output mask per
+// sample; masks may be nil
+func (nn *NeuralNetwork) maskedBatchGradients(inputs, targets, masks [][]float64, loss LossFunc) (float64, [][][]float64, [][]float64) {
 	batchSize := len(inputs)
 
 	layerGrads := make([][][]float64, len(nn.Layers))
@@ -54,35 +323,72 @@ func (nn *NeuralNetwork) TrainBatch(inputs, targets [][]float64, learningRate fl
 		layerBiasGrads[i] = make([]float64, len(layer.Biases))
 	}
 
+	totalLoss := 0.0
 	for idx := 0; idx < batchSize; idx++ {
 		output := nn.Forward(inputs[idx])
-		_, grad := CrossEntropyLoss(output, targets[idx])
+		nn.checkTarget(targets[idx])
+		sampleLossFn := loss
+		if masks != nil {
+			sampleLossFn = MaskedLoss(loss, masks[idx])
+		}
+		sampleLoss, grad := sampleLossFn(output, targets[idx])
+		totalLoss += sampleLoss
 		errorGrad := grad
+		if nn.LossScale > 0 {
+			errorGrad = make([]float64, len(grad))
+			for k, g := range grad {
+				errorGrad[k] = g * nn.LossScale
+			}
+		}
 
 		for l := len(nn.Layers) - 1; l >= 0; l-- {
 			layer := nn.Layers[l]
-			layer.Backward(errorGrad, 0) // no weight update yet
-			errorGrad = make([]float64, len(layer.inputs))
+			errorGrad = layer.Backward(errorGrad, 0) // no weight update yet
 
-			for j := range layer.inputs {
-				for k := range layer.deltas {
-					errorGrad[j] += layer.deltas[k] * layer.Weights[k][j]
-					layerGrads[l][k][j] += layer.deltas[k] * layer.inputs[j]
-				}
-			}
+			scale := layer.weightScale()
 			for k := range layer.deltas {
+				for j := range layer.inputs {
+					layerGrads[l][k][j] += scale * layer.deltas[k] * layer.inputs[j]
+				}
 				layerBiasGrads[l][k] += layer.deltas[k]
 			}
 		}
 	}
 
+	if batchSize == 0 {
+		return 0, layerGrads, layerBiasGrads
+	}
+	scale := 1 / float64(batchSize)
+	gradScale := scale
+	if nn.LossScale > 0 {
+		gradScale /= nn.LossScale
+	}
+	for i := range layerGrads {
+		for j := range layerGrads[i] {
+			for k := range layerGrads[i][j] {
+				layerGrads[i][j][k] *= gradScale
+			}
+			layerBiasGrads[i][j] *= gradScale
+		}
+	}
+	return totalLoss * scale, layerGrads, layerBiasGrads
+}
+
+// applyGradients steps every layer's parameters through the optimizer
+func (nn *NeuralNetwork) applyGradients(weightGrads [][][]float64, biasGrads [][]float64, learningRate float64) {
+	var opt Optimizer = SGD{}
+	if nn.Optimizer != nil {
+		opt = nn.Optimizer
+	}
+	nn.applyGradientsWith(opt, weightGrads, biasGrads, learningRate)
+}
+
+func (nn *NeuralNetwork) applyGradientsWith(opt Optimizer, weightGrads [][][]float64, biasGrads [][]float64, learningRate float64) {
 	for i, layer := range nn.Layers {
 		for j := range layer.Weights {
-			for k := range layer.Weights[j] {
-				layer.Weights[j][k] -= learningRate * layerGrads[i][j][k] / float64(batchSize)
-			}
-			layer.Biases[j] -= learningRate * layerBiasGrads[i][j] / float64(batchSize)
+			opt.Step(layer.Weights[j], weightGrads[i][j], learningRate)
 		}
+		opt.Step(layer.Biases, biasGrads[i], learningRate)
 	}
 }
 
@@ -91,6 +397,163 @@ func (nn *NeuralNetwork) Predict(input []float64) []float64 {
 	return nn.Forward(input)
 }
 
+// Logits returns the final layer's pre-activation output (before softmax or
+// any other output activation)
+func (nn *NeuralNetwork) Logits(input []float64) []float64 {
+	preActivations, _ := nn.forwardAll(input)
+	if len(preActivations) == 0 {
+		return nil
+	}
+	return preActivations[len(preActivations)-1]
+}
+
+// PredictWithTemperature returns softmax(logits / temperature). Temperatures
+// above 1 flatten the distribution and below 1 sharpen it; 1 matches a
+// softmax output layer.
+func (nn *NeuralNetwork) PredictWithTemperature(input []float64, temperature float64) []float64 {
+	if temperature <= 0 {
+		panic("PredictWithTemperature: temperature must be positive")
+	}
+	logits := nn.Logits(input)
+	return (&Softmax{}).ActivateVector(ScalarMultiply(logits, 1/temperature))
+}
+
+// PredictSparse runs a forward pass for an input given as non-zero indices
+// and their values. The first layer only sums the matching weight columns,
+// which is much cheaper than Predict for large, mostly-zero inputs.
+func (nn *NeuralNetwork) PredictSparse(indices []int, values []float64) []float64 {
+	if len(indices) != len(values) {
+		panic("PredictSparse: indices and values must be the same length")
+	}
+	if len(nn.Layers) == 0 {
+		return nil
+	}
+	for _, idx := range indices {
+		if idx < 0 || idx >= nn.InputSize() {
+			panic(fmt.Sprintf("PredictSparse: index %d out of range for input size %d", idx, nn.InputSize()))
+		}
+	}
+
+	first := nn.Layers[0]
+	scale := first.weightScale()
+	sums := make([]float64, len(first.Weights))
+	for i, row := range first.Weights {
+		sum := 0.0
+		for k, idx := range indices {
+			sum += row[idx] * values[k]
+		}
+		sums[i] = first.Biases[i] + scale*sum
+	}
+	output := first.activate(sums)
+	for _, layer := range nn.Layers[1:] {
+		_, output = layer.compute(output)
+	}
+	return output
+}
+
+// Trace returns the output of every layer in order, the last entry being the
+// network output. The training caches are left untouched.
+func (nn *NeuralNetwork) Trace(input []float64) [][]float64 {
+	_, outputs := nn.forwardAll(input)
+	return outputs
+}
+
+// ForwardWithCache runs a forward pass for custom backward passes and returns
+// the network output, every layer's pre-activations (zs) and the activations
+// feeding and leaving each layer: activations[0] is a copy of input and
+// activations[i+1] is the output of layer i. The training caches are left
+// untouched.
+func (nn *NeuralNetwork) ForwardWithCache(input []float64) (output []float64, preActivations, activations [][]float64) {
+	preActivations, outputs := nn.forwardAll(input)
+	activations = append([][]float64{append([]float64(nil), input...)}, outputs...)
+	return activations[len(activations)-1], preActivations, activations
+}
+
+// NumParameters returns the total number of weights and biases
+func (nn *NeuralNetwork) NumParameters() int {
+	count := 0
+	for _, layer := range nn.Layers {
+		for _, row := range layer.Weights {
+			count += len(row)
+		}
+		count += len(layer.Biases)
+	}
+	return count
+}
+
+// MemoryBytes estimates the parameter footprint in bytes (8 per float64),
+// ignoring slice headers and the per-layer forward caches.
+func (nn *NeuralNetwork) MemoryBytes() int {
+	return nn.NumParameters() * 8
+}
+
+// outputType resolves OutputType, deriving it from the final layer when unset
+func (nn *NeuralNetwork) outputType() string {
+	if nn.OutputType != "" {
+		return nn.OutputType
+	}
+	if len(nn.Layers) > 0 {
+		if _, ok := nn.Layers[len(nn.Layers)-1].Activation.(*Softmax); ok {
+			return OutputProbs
+		}
+	}
+	return OutputLogits
+}
+
+// Probabilities returns class probabilities, applying softmax when the
+// network outputs raw logits
+func (nn *NeuralNetwork) Probabilities(input []float64) []float64 {
+	output := nn.Predict(input)
+	if nn.outputType() == OutputLogits {
+		output = (&Softmax{}).ActivateVector(output)
+	}
+	return output
+}
+
+// Classify returns the most likely class and its probability
+func (nn *NeuralNetwork) Classify(input []float64) (int, float64) {
+	probs := nn.Probabilities(input)
+	class := ArgMax(probs)
+	if class < 0 {
+		return -1, 0
+	}
+	return class, probs[class]
+}
+
+// Evaluate returns the mean cross-entropy loss and accuracy over a labelled
+// set, using Probabilities so logit and probability outputs are both handled
+func (nn *NeuralNetwork) Evaluate(inputs, targets [][]float64) (loss, accuracy float64) {
+	if len(inputs) == 0 || len(inputs) != len(targets) {
+		return 0, 0
+	}
+	preds := make([][]float64, len(inputs))
+	for i, input := range inputs {
+		preds[i] = nn.Probabilities(input)
+		l, _ := CrossEntropyLoss(preds[i], targets[i])
+		loss += l
+	}
+	return loss / float64(len(inputs)), Accuracy(preds, targets)
+}
+
+// PerSampleLosses returns each sample's loss without updating the weights,
+// for finding hard examples. A nil loss uses cross-entropy on Probabilities,
+// as in Evaluate; otherwise loss sees the raw network output.
+func (nn *NeuralNetwork) PerSampleLosses(inputs, targets [][]float64, loss LossFunc) []float64 {
+	if len(inputs) != len(targets) {
+		panic("PerSampleLosses: inputs and targets must be the same length")
+	}
+	losses := make([]float64, len(inputs))
+	for i, input := range inputs {
+		nn.checkTarget(targets[i])
+		if loss == nil {
+			losses[i], _ = CrossEntropyLoss(nn.Probabilities(input), targets[i])
+		} else {
+			losses[i], _ = loss(nn.Predict(input), targets[i])
+		}
+	}
+	return losses
+}
+
 // PrintWeights for debug
 func (nn *NeuralNetwork) PrintWeights() {
 	for i, layer := range nn.Layers {
@@ -101,3 +564,27 @@ func (nn *NeuralNetwork) PrintWeights() {
 		fmt.Printf("Biases: %v\n", layer.Biases)
 	}
 }
+
+// snapshot copies the weights and biases of every layer
+func (nn *NeuralNetwork) snapshot() ([][][]float64, [][]float64) {
+	weights := make([][][]float64, len(nn.Layers))
+	biases := make([][]float64, len(nn.Layers))
+	for i, layer := range nn.Layers {
+		weights[i] = make([][]float64, len(layer.Weights))
+		for j, row := range layer.Weights {
+			weights[i][j] = append([]float64(nil), row...)
+		}
+		biases[i] = append([]float64(nil), layer.Biases...)
+	}
+	return weights, biases
+}
+
+// restore copies weights and biases taken by snapshot back into the layers
+func (nn *NeuralNetwork) restore(weights [][][]float64, biases [][]float64) {
+	for i, layer := range nn.Layers {
+		for j := range layer.Weights {
+			copy(layer.Weights[j], weights[i][j])
+		}
+		copy(layer.Biases, biases[i])
+	}
+}
@@ -0,0 +1,478 @@
+package nnlib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNumParametersAndMemoryBytes(t *testing.T) {
+	// 3->4->2: (3*4 + 4) + (4*2 + 2) = 26 parameters.
+	nn := NewNeuralNetwork([]int{3, 4, 2}, []ActivationFunc{ReLU{}, &Softmax{}})
+	if got := nn.NumParameters(); got != 26 {
+		t.Errorf("NumParameters = %d, want 26", got)
+	}
+	if got := nn.MemoryBytes(); got != 26*8 {
+		t.Errorf("MemoryBytes = %d, want %d", got, 26*8)
+	}
+}
+
+func approx(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+// expectPanic runs f and returns the panic message, failing if f returns
+// normally.
+func expectPanic(t *testing.T, f func()) (msg string) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expected a panic")
+		}
+		msg = fmt.Sprint(r)
+	}()
+	f()
+	return ""
+}
+
+func TestDimensionChecks(t *testing.T) {
+	nn := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{&Softmax{}})
+
+	msg := expectPanic(t, func() { nn.Predict([]float64{1, 2}) })
+	if !strings.Contains(msg, "input has 2 values but the network expects 3") {
+		t.Errorf("Predict panic = %q", msg)
+	}
+	expectPanic(t, func() { nn.Forward([]float64{1, 2, 3, 4}) })
+	expectPanic(t, func() { nn.Train([]float64{1}, []float64{1, 0}, 0.1) })
+
+	msg = expectPanic(t, func() { nn.Train([]float64{1, 2, 3}, []float64{1, 0, 0}, 0.1) })
+	if !strings.Contains(msg, "target has 3 values but the network outputs 2") {
+		t.Errorf("Train panic = %q", msg)
+	}
+}
+
+func TestOnGradientReportsNorm(t *testing.T) {
+	nn := NewNeuralNetwork([]int{1, 1}, []ActivationFunc{Linear{}})
+	nn.Layers[0].Weights = [][]float64{{0}}
+	nn.Layers[0].Biases = []float64{0}
+	var norms []float64
+	nn.OnGradient(func(norm float64) { norms = append(norms, norm) })
+
+	// With output 0, MSE gives dL/dy = -2·target, so dW = -2·target·x and
+	// dB = -2·target.
+	nn.trainBatch([][]float64{{1000}}, [][]float64{{1e6}}, 0, MSELoss)
+	nn.trainBatch([][]float64{{1}}, [][]float64{{1}}, 0, MSELoss)
+	if len(norms) != 2 {
+		t.Fatalf("hook ran %d times, want 2", len(norms))
+	}
+	if want := 2e6 * math.Sqrt(1e6+1); !approx(norms[0], want, 1e-6*want) {
+		t.Errorf("huge-gradient norm = %v, want %v", norms[0], want)
+	}
+	if want := 2 * math.Sqrt2; !approx(norms[1], want, 1e-12) {
+		t.Errorf("small-gradient norm = %v, want %v", norms[1], want)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	nn := xorNet()
+	nn.Forward([]float64{0, 0})
+	cached := nn.Layers[1].outputs[0]
+
+	trace := nn.Trace([]float64{0, 1})
+	if nn.Layers[1].outputs[0] != cached {
+		t.Error("Trace overwrote the training cache")
+	}
+	if len(trace) != len(nn.Layers) {
+		t.Fatalf("len(trace) = %d, want %d layers", len(trace), len(nn.Layers))
+	}
+	// Hidden units are OR (on) and NAND (on) for input 0,1.
+	if h := trace[0]; !approx(h[0], 1, 1e-4) || !approx(h[1], 1, 1e-4) {
+		t.Errorf("hidden activations = %v, want ≈ [1 1]", h)
+	}
+	if got, want := trace[1][0], nn.Predict([]float64{0, 1})[0]; got != want {
+		t.Errorf("last trace entry = %v, want Predict output %v", got, want)
+	}
+}
+
+func TestPredictSparseMatchesDense(t *testing.T) {
+	SetSeed(4)
+	nn := NewNeuralNetwork([]int{50, 8, 3}, []ActivationFunc{ReLU{}, &Softmax{}})
+	indices, values := []int{3, 17, 42}, []float64{1, -0.5, 2}
+	dense := make([]float64, 50)
+	for k, idx := range indices {
+		dense[idx] = values[k]
+	}
+
+	got, want := nn.PredictSparse(indices, values), nn.Predict(dense)
+	for i := range want {
+		if !approx(got[i], want[i], 1e-12) {
+			t.Fatalf("PredictSparse = %v, want %v", got, want)
+		}
+	}
+	expectPanic(t, func() { nn.PredictSparse([]int{50}, []float64{1}) })
+}
+
+func oneHotNet() (*NeuralNetwork, []float64) {
+	SetSeed(5)
+	nn := NewNeuralNetwork([]int{10000, 64, 10}, []ActivationFunc{ReLU{}, &Softmax{}})
+	dense := make([]float64, 10000)
+	dense[1234] = 1
+	return nn, dense
+}
+
+func BenchmarkPredictDenseOneHot(b *testing.B) {
+	nn, dense := oneHotNet()
+	for i := 0; i < b.N; i++ {
+		nn.Predict(dense)
+	}
+}
+
+func BenchmarkPredictSparseOneHot(b *testing.B) {
+	nn, _ := oneHotNet()
+	indices, values := []int{1234}, []float64{1}
+	for i := 0; i < b.N; i++ {
+		nn.PredictSparse(indices, values)
+	}
+}
+
+func TestPredictWithTemperature(t *testing.T) {
+	SetSeed(6)
+	nn := NewNeuralNetwork([]int{2, 3, 4}, []ActivationFunc{Tanh{}, &Softmax{}})
+	nn.Layers[1].Biases = []float64{1, 0, -1, 2}
+	input := []float64{0.5, -0.5}
+
+	base := nn.Predict(input)
+	same := nn.PredictWithTemperature(input, 1)
+	for i := range base {
+		if !approx(same[i], base[i], 1e-12) {
+			t.Fatalf("temperature 1 = %v, want softmax output %v", same, base)
+		}
+	}
+
+	// Higher temperatures move the top probability down and the bottom one
+	// up, towards uniform.
+	prevMax, prevMin := 1.0, 0.0
+	for _, temp := range []float64{0.5, 1, 2, 10} {
+		p := nn.PredictWithTemperature(input, temp)
+		hi, lo := p[ArgMax(p)], p[0]
+		for _, v := range p {
+			lo = math.Min(lo, v)
+		}
+		if hi >= prevMax || lo <= prevMin {
+			t.Errorf("temperature %v: max %v, min %v did not flatten from %v, %v", temp, hi, lo, prevMax, prevMin)
+		}
+		prevMax, prevMin = hi, lo
+	}
+}
+
+func TestCheckFiniteRollsBack(t *testing.T) {
+	nn := NewNeuralNetwork([]int{2, 2}, []ActivationFunc{Linear{}})
+	nn.CheckFinite = true
+	weights, biases := nn.snapshot()
+
+	weightGrads := [][][]float64{{{math.Inf(1), 0}, {0, 0}}}
+	biasGrads := [][]float64{{0, 0}}
+	err := nn.step(weightGrads, biasGrads, 0.1)
+	if err == nil || !strings.Contains(err.Error(), "layer 0") {
+		t.Fatalf("step error = %v, want a non-finite error naming layer 0", err)
+	}
+	if !reflect.DeepEqual(nn.Layers[0].Weights, weights[0]) || !reflect.DeepEqual(nn.Layers[0].Biases, biases[0]) {
+		t.Error("parameters were not restored after the non-finite update")
+	}
+
+	// A huge but finite target overflows the weights through training too.
+	if err := nn.TrainWithLoss([]float64{1e200, 1e200}, []float64{1e300, 0}, 1e100, MSELoss); err == nil {
+		t.Error("TrainWithLoss returned no error for an overflowing update")
+	}
+	if _, ok := nn.firstNonFinite(); ok {
+		t.Error("non-finite parameters survived CheckFinite")
+	}
+
+	nn.CheckFinite = false
+	nn.step(weightGrads, biasGrads, 0.1)
+	if _, ok := nn.firstNonFinite(); !ok {
+		t.Error("without CheckFinite the Inf update should have been applied")
+	}
+}
+
+// zeroGradients returns all-zero gradients shaped like nn's parameters.
+func zeroGradients(nn *NeuralNetwork) ([][][]float64, [][]float64) {
+	weightGrads := make([][][]float64, len(nn.Layers))
+	biasGrads := make([][]float64, len(nn.Layers))
+	for i, layer := range nn.Layers {
+		weightGrads[i] = make([][]float64, len(layer.Weights))
+		for j, row := range layer.Weights {
+			weightGrads[i][j] = make([]float64, len(row))
+		}
+		biasGrads[i] = make([]float64, len(layer.Biases))
+	}
+	return weightGrads, biasGrads
+}
+
+func TestGradientNoise(t *testing.T) {
+	const lr, stddev = 0.1, 0.5
+	nn := NewNeuralNetwork([]int{40, 50}, []ActivationFunc{Linear{}})
+	weights, biases := nn.snapshot()
+	noisyStep := func(seed int64) [][][]float64 {
+		nn.restore(weights, biases)
+		SetSeed(seed)
+		weightGrads, biasGrads := zeroGradients(nn)
+		nn.step(weightGrads, biasGrads, lr)
+		w, _ := nn.snapshot()
+		return w
+	}
+
+	// Zero gradients make the update -lr times the noise alone.
+	nn.GradientNoiseStddev = stddev
+	noisy := noisyStep(1)
+	sumSq, count := 0.0, 0
+	for j, row := range noisy[0] {
+		for k, w := range row {
+			d := (w - weights[0][j][k]) / lr
+			sumSq += d * d
+			count++
+		}
+	}
+	if got := math.Sqrt(sumSq / float64(count)); !approx(got, stddev, 0.05*stddev) {
+		t.Errorf("noise stddev = %v, want %v", got, stddev)
+	}
+	if !reflect.DeepEqual(noisyStep(1), noisy) {
+		t.Error("a fixed seed did not reproduce the noisy update")
+	}
+
+	nn.GradientNoiseStddev = 0
+	if !reflect.DeepEqual(noisyStep(1), weights) {
+		t.Error("stddev 0 changed the parameters on a zero gradient")
+	}
+
+	nn.GradientNoiseStddev, nn.GradientNoiseDecay, nn.updates = stddev, 0.55, 3
+	if got, want := nn.CurrentGradientNoise(), stddev/math.Pow(4, 0.275); !approx(got, want, 1e-12) {
+		t.Errorf("decayed noise after 3 updates = %v, want %v", got, want)
+	}
+}
+
+func TestApplyGradientsMatchesTrainBatch(t *testing.T) {
+	SetSeed(5)
+	inputs, targets := blobs(rand.New(rand.NewSource(5)), 8)
+	split := NewMLP(2, []int{3}, 2, Tanh{}, &Softmax{})
+	whole := NewMLP(2, []int{3}, 2, Tanh{}, &Softmax{})
+	whole.restore(split.snapshot())
+
+	for i := 0; i < 3; i++ {
+		weightGrads, biasGrads := split.ComputeBatchGradients(inputs, targets)
+		if err := split.ApplyGradients(weightGrads, biasGrads, 0.3); err != nil {
+			t.Fatal(err)
+		}
+		if err := whole.TrainBatch(inputs, targets, 0.3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w1, b1 := split.snapshot()
+	w2, b2 := whole.snapshot()
+	if !reflect.DeepEqual(w1, w2) || !reflect.DeepEqual(b1, b2) {
+		t.Error("ComputeBatchGradients + ApplyGradients diverged from TrainBatch")
+	}
+
+	weightGrads, biasGrads := split.ComputeBatchGradients(inputs, targets)
+	if err := split.ApplyGradients(weightGrads[:1], biasGrads, 0.3); !errors.Is(err, ErrShapeMismatch) {
+		t.Errorf("short gradients: error %v, want ErrShapeMismatch", err)
+	}
+	if w, _ := split.snapshot(); !reflect.DeepEqual(w, w1) {
+		t.Error("a rejected ApplyGradients changed the weights")
+	}
+}
+
+func TestLossScaleMatchesUnscaled(t *testing.T) {
+	SetSeed(4)
+	inputs, targets := blobs(rand.New(rand.NewSource(4)), 10)
+	plain := NewMLP(2, []int{4}, 2, Tanh{}, &Softmax{})
+	for _, scale := range []float64{1024, 1000} {
+		scaled := NewMLP(2, []int{4}, 2, Tanh{}, &Softmax{})
+		scaled.restore(plain.snapshot())
+		scaled.LossScale = scale
+		reference := NewMLP(2, []int{4}, 2, Tanh{}, &Softmax{})
+		reference.restore(plain.snapshot())
+
+		w1, b1 := scaled.ComputeBatchGradients(inputs, targets)
+		w2, b2 := reference.ComputeBatchGradients(inputs, targets)
+		if !approx(w1[0][1][0], w2[0][1][0], 1e-15) || !approx(b1[1][0], b2[1][0], 1e-15) {
+			t.Errorf("scale %v: ComputeBatchGradients returned scaled gradients", scale)
+		}
+
+		for i := 0; i < 5; i++ {
+			scaled.TrainBatch(inputs, targets, 0.5)
+			reference.TrainBatch(inputs, targets, 0.5)
+		}
+		got, _ := scaled.snapshot()
+		want, _ := reference.snapshot()
+		for l := range want {
+			for j := range want[l] {
+				for k := range want[l][j] {
+					if !approx(got[l][j][k], want[l][j][k], 1e-12) {
+						t.Fatalf("scale %v: weight %d,%d,%d = %v, want unscaled %v", scale, l, j, k, got[l][j][k], want[l][j][k])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGradientNoiseLeavesCallerGradients(t *testing.T) {
+	SetSeed(2)
+	nn := NewMLP(3, []int{4}, 2, Tanh{}, Linear{})
+	nn.GradientNoiseStddev = 1
+	weightGrads, biasGrads := zeroGradients(nn)
+	wantWeights, wantBiases := zeroGradients(nn)
+
+	// Aggregated gradients are often reused, e.g. applied to several
+	// replicas; the noise must not leak back into them.
+	for i := 0; i < 2; i++ {
+		if err := nn.ApplyGradients(weightGrads, biasGrads, 0.1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(weightGrads, wantWeights) || !reflect.DeepEqual(biasGrads, wantBiases) {
+		t.Error("ApplyGradients added the gradient noise to the caller's slices")
+	}
+}
+
+func TestForwardWithCache(t *testing.T) {
+	SetSeed(9)
+	nn := NewNeuralNetwork([]int{3, 4, 2}, []ActivationFunc{Tanh{}, &Softmax{}})
+	nn.Forward([]float64{0, 0, 0})
+	cached := append([]float64(nil), nn.Layers[0].preActivations...)
+
+	input := []float64{0.2, -0.6, 1}
+	output, zs, activations := nn.ForwardWithCache(input)
+	if len(zs) != 2 || len(activations) != 3 {
+		t.Fatalf("got %d pre-activations and %d activations, want 2 and 3", len(zs), len(activations))
+	}
+	for l, layer := range nn.Layers {
+		for i, row := range layer.Weights {
+			z := layer.Biases[i]
+			for j, w := range row {
+				z += w * activations[l][j]
+			}
+			if !approx(zs[l][i], z, 1e-12) {
+				t.Errorf("zs[%d][%d] = %v, want %v", l, i, zs[l][i], z)
+			}
+		}
+	}
+	if !approx(activations[1][0], math.Tanh(zs[0][0]), 1e-12) {
+		t.Errorf("hidden activation = %v, want tanh(z) = %v", activations[1][0], math.Tanh(zs[0][0]))
+	}
+	if !reflect.DeepEqual(output, nn.Trace(input)[1]) {
+		t.Errorf("output = %v, want the network output", output)
+	}
+
+	activations[0][0] = 99
+	if input[0] != 0.2 {
+		t.Error("activations[0] aliases the caller's input")
+	}
+	if !reflect.DeepEqual(nn.Layers[0].preActivations, cached) {
+		t.Error("ForwardWithCache overwrote the training cache")
+	}
+}
+
+func TestNewMLP(t *testing.T) {
+	nn := NewMLP(5, []int{8, 6}, 2, ReLU{}, Sigmoid{})
+	wantSizes := [][2]int{{5, 8}, {8, 6}, {6, 2}}
+	if len(nn.Layers) != len(wantSizes) {
+		t.Fatalf("got %d layers, want %d", len(nn.Layers), len(wantSizes))
+	}
+	for i, layer := range nn.Layers {
+		in, out := len(layer.Weights[0]), len(layer.Weights)
+		if in != wantSizes[i][0] || out != wantSizes[i][1] {
+			t.Errorf("layer %d is %d->%d, want %d->%d", i, in, out, wantSizes[i][0], wantSizes[i][1])
+		}
+	}
+	for i, want := range []ActivationFunc{ReLU{}, ReLU{}, Sigmoid{}} {
+		if reflect.TypeOf(nn.Layers[i].Activation) != reflect.TypeOf(want) {
+			t.Errorf("layer %d activation = %T, want %T", i, nn.Layers[i].Activation, want)
+		}
+	}
+
+	noHidden := NewMLP(3, nil, 1, ReLU{}, Linear{})
+	if len(noHidden.Layers) != 1 || noHidden.InputSize() != 3 || noHidden.OutputSize() != 1 {
+		t.Errorf("no hidden layers: got %d layers %d->%d", len(noHidden.Layers), noHidden.InputSize(), noHidden.OutputSize())
+	}
+	if _, ok := noHidden.Layers[0].Activation.(Linear); !ok {
+		t.Errorf("no hidden layers: activation = %T, want the output activation", noHidden.Layers[0].Activation)
+	}
+}
+
+func TestPerSampleLossesFindsMislabeled(t *testing.T) {
+	SetSeed(1)
+	inputs, targets := blobs(rand.New(rand.NewSource(2)), 20)
+	nn := NewMLP(2, []int{4}, 2, Tanh{}, &Softmax{})
+	for epoch := 0; epoch < 200; epoch++ {
+		if err := nn.TrainBatch(inputs, targets, 0.5); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Flip one label after training: the network confidently disagrees.
+	const flipped = 7
+	targets[flipped] = []float64{targets[flipped][1], targets[flipped][0]}
+	weights, _ := nn.snapshot()
+	losses := nn.PerSampleLosses(inputs, targets, nil)
+	if len(losses) != len(inputs) {
+		t.Fatalf("got %d losses for %d samples", len(losses), len(inputs))
+	}
+	for i, l := range losses {
+		if i != flipped && l >= losses[flipped] {
+			t.Errorf("sample %d loss %v is not below the mislabeled sample's %v", i, l, losses[flipped])
+		}
+	}
+	if w, _ := nn.snapshot(); !reflect.DeepEqual(w, weights) {
+		t.Error("PerSampleLosses updated the weights")
+	}
+
+	mse := nn.PerSampleLosses(inputs[:1], targets[:1], MSELoss)
+	if want, _ := MSELoss(nn.Predict(inputs[0]), targets[0]); mse[0] != want {
+		t.Errorf("MSE loss = %v, want %v", mse[0], want)
+	}
+}
+
+func TestComputeBatchGradientsMatchesFiniteDifferences(t *testing.T) {
+	// CrossEntropyLoss's gradient assumes a softmax output layer.
+	SetSeed(8)
+	inputs, targets := blobs(rand.New(rand.NewSource(8)), 6)
+	nn := NewMLP(2, []int{3}, 2, Tanh{}, &Softmax{})
+	meanLoss := func() float64 {
+		return Sum(nn.PerSampleLosses(inputs, targets, nil)) / float64(len(inputs))
+	}
+	before, _ := nn.snapshot()
+	weightGrads, biasGrads := nn.ComputeBatchGradients(inputs, targets)
+	if w, _ := nn.snapshot(); !reflect.DeepEqual(w, before) {
+		t.Fatal("ComputeBatchGradients changed the weights")
+	}
+
+	const h = 1e-6
+	numeric := func(p *float64) float64 {
+		orig := *p
+		*p = orig + h
+		up := meanLoss()
+		*p = orig - h
+		down := meanLoss()
+		*p = orig
+		return (up - down) / (2 * h)
+	}
+	for i, layer := range nn.Layers {
+		for j, row := range layer.Weights {
+			for k := range row {
+				if want := numeric(&row[k]); !approx(weightGrads[i][j][k], want, 1e-6) {
+					t.Errorf("dW[%d][%d][%d] = %v, want %v", i, j, k, weightGrads[i][j][k], want)
+				}
+			}
+			if want := numeric(&layer.Biases[j]); !approx(biasGrads[i][j], want, 1e-6) {
+				t.Errorf("dB[%d][%d] = %v, want %v", i, j, biasGrads[i][j], want)
+			}
+		}
+	}
+}
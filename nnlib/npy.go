@@ -0,0 +1,128 @@
+package nnlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var npyMagic = []byte("\x93NUMPY")
+
+var (
+	npyDescrRe   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortranRe = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapeRe   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// LoadNPYWeights replaces each layer's weight matrix with a 2D little-endian
+// float64 array read from the matching .npy file. Arrays must have shape
+// (outputSize, inputSize), the same layout as Layer.Weights; biases are left
+// untouched. Nothing is modified if any file fails to load or mismatches.
+func (nn *NeuralNetwork) LoadNPYWeights(layerFiles []string) error {
+	if len(layerFiles) != len(nn.Layers) {
+		return fmt.Errorf("LoadNPYWeights: got %d files for %d layers", len(layerFiles), len(nn.Layers))
+	}
+
+	loaded := make([][][]float64, len(layerFiles))
+	for i, path := range layerFiles {
+		w, err := readNPYMatrix(path)
+		if err != nil {
+			return fmt.Errorf("LoadNPYWeights: %s: %w", path, err)
+		}
+		layer := nn.Layers[i]
+		if len(w) != len(layer.Weights) || len(w[0]) != len(layer.Weights[0]) {
+			return fmt.Errorf("LoadNPYWeights: %s: %w: (%d, %d) does not match layer %d shape (%d, %d)",
+				path, ErrShapeMismatch, len(w), len(w[0]), i, len(layer.Weights), len(layer.Weights[0]))
+		}
+		loaded[i] = w
+	}
+
+	for i, w := range loaded {
+		nn.Layers[i].Weights = w
+	}
+	return nil
+}
+
+// readNPYMatrix parses a .npy file holding a non-empty 2D '<f8' array. Format
+// problems wrap ErrMalformedNPY.
+func readNPYMatrix(path string) ([][]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 10 || !bytes.Equal(data[:6], npyMagic) {
+		return nil, fmt.Errorf("%w: not a .npy file", ErrMalformedNPY)
+	}
+
+	major := data[6]
+	var headerLen, offset int
+	switch major {
+	case 1:
+		headerLen = int(binary.LittleEndian.Uint16(data[8:10]))
+		offset = 10
+	case 2, 3:
+		if len(data) < 12 {
+			return nil, fmt.Errorf("%w: truncated header", ErrMalformedNPY)
+		}
+		headerLen = int(binary.LittleEndian.Uint32(data[8:12]))
+		offset = 12
+	default:
+		return nil, fmt.Errorf("%w: unsupported .npy version %d", ErrMalformedNPY, major)
+	}
+	if offset+headerLen > len(data) {
+		return nil, fmt.Errorf("%w: truncated header", ErrMalformedNPY)
+	}
+	header := string(data[offset : offset+headerLen])
+	body := data[offset+headerLen:]
+
+	descr := npyDescrRe.FindStringSubmatch(header)
+	if descr == nil || descr[1] != "<f8" {
+		return nil, fmt.Errorf("%w: dtype must be little-endian float64 ('<f8')", ErrMalformedNPY)
+	}
+	fortran := npyFortranRe.FindStringSubmatch(header)
+	if fortran == nil {
+		return nil, fmt.Errorf("%w: missing fortran_order", ErrMalformedNPY)
+	}
+	shapeMatch := npyShapeRe.FindStringSubmatch(header)
+	if shapeMatch == nil {
+		return nil, fmt.Errorf("%w: missing shape", ErrMalformedNPY)
+	}
+	var shape []int
+	for _, part := range strings.Split(shapeMatch[1], ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad shape %q", ErrMalformedNPY, shapeMatch[1])
+		}
+		shape = append(shape, n)
+	}
+	if len(shape) != 2 || shape[0] == 0 || shape[1] == 0 {
+		return nil, fmt.Errorf("%w: expected a non-empty 2D array, got shape (%s)", ErrMalformedNPY, shapeMatch[1])
+	}
+
+	rows, cols := shape[0], shape[1]
+	if len(body) < rows*cols*8 {
+		return nil, fmt.Errorf("%w: truncated data", ErrMalformedNPY)
+	}
+	out := make([][]float64, rows)
+	for i := range out {
+		out[i] = make([]float64, cols)
+	}
+	for k := 0; k < rows*cols; k++ {
+		v := math.Float64frombits(binary.LittleEndian.Uint64(body[k*8:]))
+		if fortran[1] == "True" {
+			out[k%rows][k/rows] = v
+		} else {
+			out[k/cols][k%cols] = v
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package nnlib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeNPY writes a version 1.0 .npy file holding values (already in the
+// file's memory order) with the given shape.
+func writeNPY(t *testing.T, rows, cols int, fortran bool, values []float64) string {
+	t.Helper()
+	order := "False"
+	if fortran {
+		order = "True"
+	}
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': %s, 'shape': (%d, %d), }", order, rows, cols)
+	// Pad so magic (6) + version (2) + length (2) + header ends on 64 bytes.
+	header += strings.Repeat(" ", 63-(10+len(header))%64) + "\n"
+
+	data := append([]byte("\x93NUMPY\x01\x00"), 0, 0)
+	binary.LittleEndian.PutUint16(data[8:], uint16(len(header)))
+	data = append(data, header...)
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+	}
+
+	path := filepath.Join(t.TempDir(), "w.npy")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadNPYWeights(t *testing.T) {
+	want := [][]float64{{1, 2, 3}, {4, 5, 6}}
+	for _, tc := range []struct {
+		name    string
+		fortran bool
+		values  []float64
+	}{
+		{"C order", false, []float64{1, 2, 3, 4, 5, 6}},
+		{"Fortran order", true, []float64{1, 4, 2, 5, 3, 6}},
+	} {
+		nn := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{Linear{}})
+		if err := nn.LoadNPYWeights([]string{writeNPY(t, 2, 3, tc.fortran, tc.values)}); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		for i := range want {
+			for j := range want[i] {
+				if nn.Layers[0].Weights[i][j] != want[i][j] {
+					t.Fatalf("%s: weights = %v, want %v", tc.name, nn.Layers[0].Weights, want)
+				}
+			}
+		}
+	}
+}
+
+func TestLoadNPYWeightsErrors(t *testing.T) {
+	nn := NewNeuralNetwork([]int{3, 2}, []ActivationFunc{Linear{}})
+	orig := nn.Layers[0].Weights
+
+	err := nn.LoadNPYWeights([]string{writeNPY(t, 3, 2, false, make([]float64, 6))})
+	if !errors.Is(err, ErrShapeMismatch) {
+		t.Errorf("shape mismatch: err = %v, want ErrShapeMismatch", err)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.npy")
+	os.WriteFile(bad, []byte("not numpy at all"), 0644)
+	if err := nn.LoadNPYWeights([]string{bad}); !errors.Is(err, ErrMalformedNPY) {
+		t.Errorf("malformed file: err = %v, want ErrMalformedNPY", err)
+	}
+	if &nn.Layers[0].Weights[0][0] != &orig[0][0] {
+		t.Error("a failed load replaced the weights")
+	}
+}
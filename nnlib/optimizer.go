@@ -0,0 +1,42 @@
+package nnlib
+
+import "math"
+
+// Optimizer updates a parameter slice in place from its gradient.
+type Optimizer interface {
+	Step(params, grads []float64, learningRate float64)
+}
+
+// --------------------
+// SGD: params -= lr * grad
+// --------------------
+type SGD struct{}
+
+func (SGD) Step(params, grads []float64, learningRate float64) {
+	for i := range params {
+		params[i] -= learningRate * grads[i]
+	}
+}
+
+// --------------------
+// SGLD: stochastic gradient Langevin dynamics
+// params -= lr * grad + NoiseScale * sqrt(2 * lr) * N(0, 1)
+// --------------------
+type SGLD struct {
+	NoiseScale float64 // 0 reduces SGLD to SGD
+}
+
+// NewSGLD returns an SGLD optimizer with the standard unit noise scale
+func NewSGLD() *SGLD {
+	return &SGLD{NoiseScale: 1}
+}
+
+func (s *SGLD) Step(params, grads []float64, learningRate float64) {
+	noiseStd := s.NoiseScale * math.Sqrt(2*learningRate)
+	for i := range params {
+		params[i] -= learningRate * grads[i]
+		if noiseStd != 0 {
+			params[i] -= noiseStd * rng.NormFloat64()
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package nnlib
+
+import "testing"
+
+// sgldVariance returns the sample variance of one SGLD step from zero
+// parameters with zero gradients, which is pure noise.
+func sgldVariance(opt *SGLD, learningRate float64) float64 {
+	params := make([]float64, 20000)
+	opt.Step(params, make([]float64, len(params)), learningRate)
+	mean := Sum(params) / float64(len(params))
+	variance := 0.0
+	for _, p := range params {
+		variance += (p - mean) * (p - mean)
+	}
+	return variance / float64(len(params)-1)
+}
+
+func TestSGLDNoiseScalesWithLearningRate(t *testing.T) {
+	SetSeed(5)
+	for _, lr := range []float64{0.01, 0.1} {
+		// The noise is NoiseScale * sqrt(2 lr) * N(0, 1), so its variance is 2 lr.
+		if got := sgldVariance(NewSGLD(), lr); !approx(got, 2*lr, 0.05*2*lr) {
+			t.Errorf("lr %v: noise variance = %v, want about %v", lr, got, 2*lr)
+		}
+	}
+}
+
+func TestSGLDWithoutNoiseIsSGD(t *testing.T) {
+	sgld := []float64{1, -2, 3}
+	sgd := []float64{1, -2, 3}
+	grads := []float64{0.5, 0.5, -1}
+	(&SGLD{}).Step(sgld, grads, 0.1)
+	SGD{}.Step(sgd, grads, 0.1)
+	for i := range sgd {
+		if sgld[i] != sgd[i] {
+			t.Fatalf("SGLD with zero noise = %v, SGD = %v", sgld, sgd)
+		}
+	}
+}
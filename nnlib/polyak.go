@@ -0,0 +1,68 @@
+package nnlib
+
+import "fmt"
+
+// EnablePolyakAveraging starts keeping an exponential moving average of the
+// weights and biases, updated after every training step as
+// shadow = decay*shadow + (1-decay)*param and seeded with the current values.
+// Use ApplyPolyakWeights to swap the average in. A decay of 0 disables it.
+func (nn *NeuralNetwork) EnablePolyakAveraging(decay float64) {
+	if decay < 0 || decay >= 1 {
+		panic("EnablePolyakAveraging: decay must be in [0, 1)")
+	}
+	nn.polyakDecay = decay
+	nn.shadowWeights, nn.shadowBiases = nil, nil
+	if decay > 0 {
+		nn.shadowWeights, nn.shadowBiases = nn.snapshot()
+	}
+}
+
+// ApplyPolyakWeights copies the moving-average weights and biases into the
+// live layers. Averaging continues from there.
+func (nn *NeuralNetwork) ApplyPolyakWeights() {
+	if nn.shadowWeights == nil {
+		panic("ApplyPolyakWeights: Polyak averaging is not enabled")
+	}
+	nn.restore(nn.shadowWeights, nn.shadowBiases)
+}
+
+// updatePolyak moves the shadow parameters toward the live ones
+func (nn *NeuralNetwork) updatePolyak() {
+	if nn.shadowWeights == nil {
+		return
+	}
+	d := nn.polyakDecay
+	for i, layer := range nn.Layers {
+		for j, row := range layer.Weights {
+			for k, w := range row {
+				nn.shadowWeights[i][j][k] = d*nn.shadowWeights[i][j][k] + (1-d)*w
+			}
+		}
+		for j, b := range layer.Biases {
+			nn.shadowBiases[i][j] = d*nn.shadowBiases[i][j] + (1-d)*b
+		}
+	}
+}
+
+// SoftUpdate moves target's weights and biases toward online's:
+// target = (1-tau)*target + tau*online. tau = 1 copies online exactly and
+// tau = 0 leaves target unchanged. The networks must have the same shape.
+func SoftUpdate(target, online *NeuralNetwork, tau float64) {
+	if len(target.Layers) != len(online.Layers) {
+		panic("SoftUpdate: networks have different numbers of layers")
+	}
+	for i, tl := range target.Layers {
+		ol := online.Layers[i]
+		if len(tl.Weights) != len(ol.Weights) || len(tl.Weights[0]) != len(ol.Weights[0]) {
+			panic(fmt.Sprintf("SoftUpdate: layer %d shapes differ", i))
+		}
+		for j, row := range tl.Weights {
+			for k := range row {
+				row[k] = (1-tau)*row[k] + tau*ol.Weights[j][k]
+			}
+		}
+		for j := range tl.Biases {
+			tl.Biases[j] = (1-tau)*tl.Biases[j] + tau*ol.Biases[j]
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package nnlib
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestPolyakLag drives every parameter down a straight line and checks the
+// average trails it by c·d/(1-d)·(1-d^t), the lag of an EMA behind a ramp
+// falling c per step.
+func TestPolyakLag(t *testing.T) {
+	SetSeed(2)
+	nn := NewMLP(3, []int{2}, 1, Tanh{}, Linear{})
+	const decay, lr = 0.8, 0.1
+	nn.EnablePolyakAveraging(decay)
+	start, _ := nn.snapshot()
+
+	weightGrads, biasGrads := zeroGradients(nn)
+	for i := range weightGrads {
+		for j := range weightGrads[i] {
+			for k := range weightGrads[i][j] {
+				weightGrads[i][j][k] = 1
+			}
+		}
+		for j := range biasGrads[i] {
+			biasGrads[i][j] = 1
+		}
+	}
+
+	const steps = 12
+	for s := 0; s < steps; s++ {
+		if err := nn.step(weightGrads, biasGrads, lr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lag := lr * decay / (1 - decay) * (1 - math.Pow(decay, steps))
+	for i, layer := range nn.Layers {
+		for j, row := range layer.Weights {
+			for k, w := range row {
+				if !approx(w, start[i][j][k]-steps*lr, 1e-12) {
+					t.Fatalf("weight %d,%d,%d = %v, want a ramp from %v", i, j, k, w, start[i][j][k])
+				}
+				if got := nn.shadowWeights[i][j][k] - w; !approx(got, lag, 1e-12) {
+					t.Errorf("shadow weight %d,%d,%d lags by %v, want %v", i, j, k, got, lag)
+				}
+			}
+		}
+		for j, b := range layer.Biases {
+			if got := nn.shadowBiases[i][j] - b; !approx(got, lag, 1e-12) {
+				t.Errorf("shadow bias %d,%d lags by %v, want %v", i, j, got, lag)
+			}
+		}
+	}
+
+	shadow := nn.shadowWeights[0][0][0]
+	nn.ApplyPolyakWeights()
+	if nn.Layers[0].Weights[0][0] != shadow {
+		t.Errorf("ApplyPolyakWeights: weight = %v, want the average %v", nn.Layers[0].Weights[0][0], shadow)
+	}
+	nn.Layers[0].Weights[0][0] = 0
+	if nn.shadowWeights[0][0][0] != shadow {
+		t.Error("ApplyPolyakWeights aliased the live weights to the average")
+	}
+}
+
+func TestPolyakDisabled(t *testing.T) {
+	nn := NewMLP(2, nil, 1, nil, Linear{})
+	expectPanic(t, nn.ApplyPolyakWeights)
+	nn.EnablePolyakAveraging(0.5)
+	nn.EnablePolyakAveraging(0)
+	if nn.shadowWeights != nil {
+		t.Error("a decay of 0 kept the average")
+	}
+	expectPanic(t, func() { nn.EnablePolyakAveraging(1) })
+}
+
+func TestSoftUpdate(t *testing.T) {
+	SetSeed(4)
+	online := NewMLP(3, []int{4}, 2, ReLU{}, Linear{})
+	target := NewMLP(3, []int{4}, 2, ReLU{}, Linear{})
+	before, beforeBiases := target.snapshot()
+	onlineWeights, onlineBiases := online.snapshot()
+
+	SoftUpdate(target, online, 0)
+	if w, b := target.snapshot(); !reflect.DeepEqual(w, before) || !reflect.DeepEqual(b, beforeBiases) {
+		t.Error("tau = 0 changed the target")
+	}
+
+	SoftUpdate(target, online, 0.25)
+	w := target.Layers[1].Weights[1][2]
+	if want := 0.75*before[1][1][2] + 0.25*onlineWeights[1][1][2]; !approx(w, want, 1e-15) {
+		t.Errorf("tau = 0.25: weight = %v, want %v", w, want)
+	}
+
+	SoftUpdate(target, online, 1)
+	if w, b := target.snapshot(); !reflect.DeepEqual(w, onlineWeights) || !reflect.DeepEqual(b, onlineBiases) {
+		t.Error("tau = 1 did not copy the online network")
+	}
+	if w, _ := online.snapshot(); !reflect.DeepEqual(w, onlineWeights) {
+		t.Error("SoftUpdate modified the online network")
+	}
+
+	expectPanic(t, func() { SoftUpdate(target, NewMLP(3, []int{5}, 2, ReLU{}, Linear{}), 0.5) })
+}
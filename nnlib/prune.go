@@ -0,0 +1,67 @@
+package nnlib
+
+import "fmt"
+
+// PruneNeurons removes every output neuron of layer layerIdx except those in
+// keep (in that order), along with the matching input columns of the next
+// layer, shrinking both weight matrices. Predictions are unchanged wherever
+// the removed neurons contributed nothing. Quantized copies of the affected
+// layers are dropped; call Quantize again if needed.
+func (nn *NeuralNetwork) PruneNeurons(layerIdx int, keep []int) error {
+	if layerIdx < 0 || layerIdx >= len(nn.Layers) {
+		return fmt.Errorf("PruneNeurons: layer %d out of range [0,%d)", layerIdx, len(nn.Layers))
+	}
+	layer := nn.Layers[layerIdx]
+	if len(keep) == 0 {
+		return fmt.Errorf("PruneNeurons: layer %d must keep at least one neuron", layerIdx)
+	}
+	seen := make(map[int]bool, len(keep))
+	for _, k := range keep {
+		if k < 0 || k >= len(layer.Weights) {
+			return fmt.Errorf("PruneNeurons: neuron %d out of range [0,%d)", k, len(layer.Weights))
+		}
+		if seen[k] {
+			return fmt.Errorf("PruneNeurons: neuron %d listed twice", k)
+		}
+		seen[k] = true
+	}
+
+	weights := make([][]float64, len(keep))
+	biases := make([]float64, len(keep))
+	for i, k := range keep {
+		weights[i] = layer.Weights[k]
+		biases[i] = layer.Biases[k]
+	}
+	layer.Weights, layer.Biases = weights, biases
+	layer.resetDerived()
+
+	if layerIdx+1 < len(nn.Layers) {
+		next := nn.Layers[layerIdx+1]
+		for i, row := range next.Weights {
+			pruned := make([]float64, len(keep))
+			for j, k := range keep {
+				pruned[j] = row[k]
+			}
+			next.Weights[i] = pruned
+		}
+		next.resetDerived()
+	}
+	nn.resetPolyak()
+	return nil
+}
+
+// resetPolyak reseeds the Polyak average from the current parameters after
+// the layer shapes change
+func (nn *NeuralNetwork) resetPolyak() {
+	if nn.shadowWeights != nil {
+		nn.shadowWeights, nn.shadowBiases = nn.snapshot()
+	}
+}
+
+// resetDerived clears state computed from the weight shapes: the quantized
+// copy, the spectral norm estimate and the forward caches
+func (l *Layer) resetDerived() {
+	l.QuantizedWeights, l.QuantScale = nil, 0
+	l.spectralU, l.sigma = nil, 0
+	l.inputs, l.preActivations, l.outputs, l.deltas = nil, nil, nil, nil
+}
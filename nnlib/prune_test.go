@@ -0,0 +1,45 @@
+package nnlib
+
+import "testing"
+
+func TestPruneNeurons(t *testing.T) {
+	SetSeed(8)
+	nn := NewNeuralNetwork([]int{2, 4, 3}, []ActivationFunc{ReLU{}, &Softmax{}})
+	// Hidden neuron 2 feeds nothing forward, so removing it is lossless.
+	for _, row := range nn.Layers[1].Weights {
+		row[2] = 0
+	}
+	inputs := [][]float64{{0.5, -1}, {2, 0.3}, {-0.7, -0.7}}
+	var before [][]float64
+	for _, x := range inputs {
+		before = append(before, nn.Predict(x))
+	}
+
+	if err := nn.PruneNeurons(0, []int{0, 1, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if len(nn.Layers[0].Weights) != 3 || len(nn.Layers[0].Biases) != 3 || len(nn.Layers[0].Weights[0]) != 2 {
+		t.Errorf("hidden layer is %dx%d with %d biases, want 3x2 with 3", len(nn.Layers[0].Weights), len(nn.Layers[0].Weights[0]), len(nn.Layers[0].Biases))
+	}
+	if len(nn.Layers[1].Weights) != 3 || len(nn.Layers[1].Weights[0]) != 3 {
+		t.Errorf("output layer is %dx%d, want 3x3", len(nn.Layers[1].Weights), len(nn.Layers[1].Weights[0]))
+	}
+	if err := nn.ValidateTopology(); err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range inputs {
+		after := nn.Predict(x)
+		for k := range after {
+			if !approx(after[k], before[i][k], 1e-12) {
+				t.Errorf("Predict(%v) = %v after pruning, want %v", x, after, before[i])
+				break
+			}
+		}
+	}
+
+	for _, keep := range [][]int{nil, {0, 0}, {5}} {
+		if err := nn.PruneNeurons(0, keep); err == nil {
+			t.Errorf("PruneNeurons(0, %v) returned no error", keep)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package nnlib
+
+import "math"
+
+// Quantize computes a symmetric int8 copy of every layer's weights with a
+// per-layer scale (max |w| / 127). The float weights are kept for training;
+// use QuantizedPredict to run inference from the int8 copy. Saved models
+// store only the quantized weights for quantized layers.
+func (nn *NeuralNetwork) Quantize() {
+	for _, layer := range nn.Layers {
+		maxAbs := 0.0
+		for _, row := range layer.Weights {
+			for _, w := range row {
+				maxAbs = math.Max(maxAbs, math.Abs(w))
+			}
+		}
+		scale := maxAbs / 127
+		if scale == 0 {
+			scale = 1
+		}
+
+		q := make([][]int8, len(layer.Weights))
+		for i, row := range layer.Weights {
+			q[i] = make([]int8, len(row))
+			for j, w := range row {
+				q[i][j] = int8(math.Round(w / scale))
+			}
+		}
+		layer.QuantizedWeights = q
+		layer.QuantScale = scale
+	}
+}
+
+// QuantizedPredict runs a forward pass dequantizing int8 weights on the fly,
+// applying spectral normalization as Predict does. Layers that have not been
+// quantized use their float weights. The training caches are left untouched.
+func (nn *NeuralNetwork) QuantizedPredict(input []float64) []float64 {
+	for _, layer := range nn.Layers {
+		if layer.QuantizedWeights == nil {
+			_, input = layer.compute(input)
+			continue
+		}
+		scale := layer.QuantScale * layer.weightScale()
+		output := make([]float64, len(layer.QuantizedWeights))
+		for i, row := range layer.QuantizedWeights {
+			sum := 0.0
+			for j, q := range row {
+				sum += float64(q) * input[j]
+			}
+			output[i] = sum*scale + layer.Biases[i]
+		}
+		input = layer.activate(output)
+	}
+	return input
+}
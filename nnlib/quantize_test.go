@@ -0,0 +1,51 @@
+package nnlib
+
+import (
+	"math"
+	"testing"
+)
+
+// xorNet returns a 2-2-1 sigmoid network wired by hand to compute XOR: the
+// hidden units are OR and NAND, and the output is their AND.
+func xorNet() *NeuralNetwork {
+	nn := NewNeuralNetwork([]int{2, 2, 1}, []ActivationFunc{Sigmoid{}, Sigmoid{}})
+	nn.Layers[0].Weights = [][]float64{{20, 20}, {-20, -20}}
+	nn.Layers[0].Biases = []float64{-10, 30}
+	nn.Layers[1].Weights = [][]float64{{20, 20}}
+	nn.Layers[1].Biases = []float64{-30}
+	return nn
+}
+
+var xorCases = []struct{ input, target []float64 }{
+	{[]float64{0, 0}, []float64{0}},
+	{[]float64{0, 1}, []float64{1}},
+	{[]float64{1, 0}, []float64{1}},
+	{[]float64{1, 1}, []float64{0}},
+}
+
+func TestQuantizedPredictMatchesFloat(t *testing.T) {
+	nn := xorNet()
+	nn.Quantize()
+	for _, c := range xorCases {
+		want := nn.Predict(c.input)[0]
+		got := nn.QuantizedPredict(c.input)[0]
+		if math.Abs(got-want) > 1e-2 {
+			t.Errorf("QuantizedPredict(%v) = %v, float Predict = %v", c.input, got, want)
+		}
+		if math.Round(got) != c.target[0] {
+			t.Errorf("QuantizedPredict(%v) = %v, want XOR %v", c.input, got, c.target[0])
+		}
+	}
+}
+
+func TestQuantizeScale(t *testing.T) {
+	nn := xorNet()
+	nn.Quantize()
+	layer := nn.Layers[0]
+	if layer.QuantScale != 20.0/127 {
+		t.Errorf("QuantScale = %v, want max|w|/127 = %v", layer.QuantScale, 20.0/127)
+	}
+	if layer.QuantizedWeights[0][0] != 127 || layer.QuantizedWeights[1][0] != -127 {
+		t.Errorf("QuantizedWeights = %v, want ±127 at the largest weights", layer.QuantizedWeights)
+	}
+}
@@ -0,0 +1,16 @@
+package nnlib
+
+import (
+	"math/rand"
+	"time"
+)
+
+// rng is the package-wide source used for weight initialisation and any
+// stochastic updates. It is seeded from the clock unless SetSeed is called.
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// SetSeed reseeds the package RNG so that initialisation and noisy updates
+// are reproducible.
+func SetSeed(seed int64) {
+	rng = rand.New(rand.NewSource(seed))
+}
@@ -0,0 +1,35 @@
+package nnlib
+
+import "math"
+
+// Scheduler maps a zero-based epoch to the learning rate Fit uses for it.
+type Scheduler interface {
+	Rate(epoch int) float64
+}
+
+// WarmRestarts is cosine annealing with warm restarts (SGDR): within a cycle
+// the rate falls from MaxRate to MinRate along half a cosine, then jumps back
+// to MaxRate. The first cycle lasts Cycle epochs and each later one Mult
+// times as long as the one before (Mult ≤ 1 keeps them equal).
+type WarmRestarts struct {
+	MaxRate float64
+	MinRate float64
+	Cycle   int
+	Mult    int
+}
+
+// Rate returns MinRate + (MaxRate-MinRate)(1 + cos(π·t/T))/2 for epoch t of
+// the current cycle of length T.
+func (w WarmRestarts) Rate(epoch int) float64 {
+	if w.Cycle <= 0 {
+		panic("WarmRestarts: Cycle must be positive")
+	}
+	t, length := epoch, w.Cycle
+	for t >= length {
+		t -= length
+		if w.Mult > 1 {
+			length *= w.Mult
+		}
+	}
+	return w.MinRate + (w.MaxRate-w.MinRate)*(1+math.Cos(math.Pi*float64(t)/float64(length)))/2
+}
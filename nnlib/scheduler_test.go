@@ -0,0 +1,58 @@
+package nnlib
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestWarmRestarts(t *testing.T) {
+	w := WarmRestarts{MaxRate: 0.1, MinRate: 0.001, Cycle: 4, Mult: 2}
+	// Cycles of 4, 8 and 16 epochs start at 0, 4 and 12.
+	starts, lengths := []int{0, 4, 12}, []int{4, 8, 16}
+	for c, start := range starts {
+		if got := w.Rate(start); got != w.MaxRate {
+			t.Errorf("restart at epoch %d: rate = %v, want MaxRate", start, got)
+		}
+		for e := 0; e < lengths[c]; e++ {
+			want := w.MinRate + (w.MaxRate-w.MinRate)*(1+math.Cos(math.Pi*float64(e)/float64(lengths[c])))/2
+			if got := w.Rate(start + e); !approx(got, want, 1e-15) {
+				t.Errorf("epoch %d (cycle %d, step %d): rate = %v, want %v", start+e, c, e, got, want)
+			}
+			if e > 0 && w.Rate(start+e) >= w.Rate(start+e-1) {
+				t.Errorf("epoch %d: rate did not fall within the cycle", start+e)
+			}
+		}
+	}
+
+	if fixed := (WarmRestarts{MaxRate: 1, Cycle: 3}); fixed.Rate(6) != 1 || fixed.Rate(7) != fixed.Rate(1) {
+		t.Error("Mult 0 did not keep the cycles equal")
+	}
+	expectPanic(t, func() { WarmRestarts{MaxRate: 1}.Rate(0) })
+}
+
+// recordingSchedule remembers the epochs Fit asks about.
+type recordingSchedule struct {
+	WarmRestarts
+	epochs []int
+}
+
+func (r *recordingSchedule) Rate(epoch int) float64 {
+	r.epochs = append(r.epochs, epoch)
+	return r.WarmRestarts.Rate(epoch)
+}
+
+func TestFitUsesSchedule(t *testing.T) {
+	SetSeed(1)
+	inputs, targets := blobs(rand.New(rand.NewSource(1)), 8)
+	nn := NewMLP(2, []int{3}, 2, Tanh{}, &Softmax{})
+	schedule := &recordingSchedule{WarmRestarts: WarmRestarts{MaxRate: 0.5, MinRate: 0.01, Cycle: 2}}
+	if _, err := nn.Fit(inputs, targets, FitConfig{Epochs: 5, Schedule: schedule, Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Fatal(err)
+	}
+	for e := 0; e < 5; e++ {
+		if e >= len(schedule.epochs) || schedule.epochs[e] != e {
+			t.Fatalf("Fit asked the schedule for epochs %v, want 0 to 4", schedule.epochs)
+		}
+	}
+}
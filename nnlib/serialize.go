@@ -1,30 +1,45 @@
 package nnlib
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"strings"
 )
 
 type serialLayer struct {
-	Weights    [][]float64 `json:"weights"`
-	Biases     []float64   `json:"biases"`
-	Activation string      `json:"activation"`
+	Weights          [][]float64 `json:"weights,omitempty"`
+	QuantizedWeights [][]int8    `json:"quantized_weights,omitempty"`
+	QuantScale       float64     `json:"quant_scale,omitempty"`
+	Biases           []float64   `json:"biases"`
+	Activation       string      `json:"activation"`
+	SpectralNorm     bool        `json:"spectral_norm,omitempty"`
 }
 
 type serialModel struct {
-	Layers []serialLayer `json:"layers"`
+	Layers     []serialLayer `json:"layers"`
+	OutputType string        `json:"output_type,omitempty"`
 }
 
-// Save model to JSON file
+// Save model to JSON file, gzip-compressed if filename ends in .gz
 func (nn *NeuralNetwork) Save(filename string) error {
-	s := serialModel{}
+	s := serialModel{OutputType: nn.outputType()}
 	for _, layer := range nn.Layers {
-		s.Layers = append(s.Layers, serialLayer{
-			Weights:    layer.Weights,
-			Biases:     layer.Biases,
-			Activation: activationName(layer.Activation),
-		})
+		sl := serialLayer{
+			Weights:      layer.Weights,
+			Biases:       layer.Biases,
+			Activation:   activationName(layer.Activation),
+			SpectralNorm: layer.SpectralNorm,
+		}
+		if layer.QuantizedWeights != nil {
+			sl.Weights = nil
+			sl.QuantizedWeights = layer.QuantizedWeights
+			sl.QuantScale = layer.QuantScale
+		}
+		s.Layers = append(s.Layers, sl)
 	}
 
 	data, err := json.MarshalIndent(s, "", "  ")
@@ -32,34 +47,160 @@ func (nn *NeuralNetwork) Save(filename string) error {
 		return err
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	return writeFile(filename, data)
 }
 
-// Load model from JSON file
+// Load model from JSON file, gzip-compressed if filename ends in .gz
 func Load(filename string) (*NeuralNetwork, error) {
-	data, err := os.ReadFile(filename)
+	data, err := readFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
 	var s serialModel
 	if err := json.Unmarshal(data, &s); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Load: %s: %w: %w", filename, ErrMalformedModel, err)
 	}
 
-	nn := &NeuralNetwork{}
-	for _, l := range s.Layers {
+	nn := &NeuralNetwork{OutputType: s.OutputType}
+	for i, l := range s.Layers {
+		act, ok := lookupActivation(l.Activation)
+		if !ok {
+			return nil, fmt.Errorf("Load: %s: %w: layer %d has unknown activation %q", filename, ErrMalformedModel, i, l.Activation)
+		}
 		layer := &Layer{
-			Weights:    l.Weights,
-			Biases:     l.Biases,
-			Activation: activationFromName(l.Activation),
+			Weights:      l.Weights,
+			Biases:       l.Biases,
+			Activation:   act,
+			SpectralNorm: l.SpectralNorm,
+		}
+		if l.QuantizedWeights != nil {
+			layer.QuantizedWeights = l.QuantizedWeights
+			layer.QuantScale = l.QuantScale
+			layer.Weights = make([][]float64, len(l.QuantizedWeights))
+			for i, row := range l.QuantizedWeights {
+				layer.Weights[i] = make([]float64, len(row))
+				for j, q := range row {
+					layer.Weights[i][j] = float64(q) * l.QuantScale
+				}
+			}
 		}
 		nn.Layers = append(nn.Layers, layer)
 	}
+	if err := nn.ValidateTopology(); err != nil {
+		return nil, fmt.Errorf("Load: %s: %w", filename, err)
+	}
+	return nn, nil
+}
+
+// legacyModel is the format saved by the original single-activation network
+// (oldfiles/nn.go)
+type legacyModel struct {
+	LayerSizes     []int         `json:"layerSizes"`
+	Weights        [][][]float64 `json:"weights"`
+	Biases         [][]float64   `json:"biases"`
+	LearningRate   float64       `json:"learningRate"`
+	ActivationFunc string        `json:"activationFunc"`
+}
+
+// LoadLegacy loads a model saved by the original network in oldfiles/nn.go,
+// applying its single activationFunc to every layer. As in the original
+// LoadModel, "ReLU" selects ReLU and any other name selects Tanh. The saved
+// learning rate is ignored since nnlib passes rates to each training call.
+func LoadLegacy(filename string) (*NeuralNetwork, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var lm legacyModel
+	if err := json.Unmarshal(data, &lm); err != nil {
+		return nil, fmt.Errorf("LoadLegacy: %s: %w: %w", filename, ErrMalformedModel, err)
+	}
+	var act ActivationFunc = Tanh{}
+	if lm.ActivationFunc == "ReLU" {
+		act = ReLU{}
+	}
+	if len(lm.LayerSizes) < 2 || len(lm.Weights) != len(lm.LayerSizes)-1 || len(lm.Biases) != len(lm.Weights) {
+		return nil, fmt.Errorf("LoadLegacy: %s: %w: %d layer sizes, %d weight matrices and %d bias vectors don't match",
+			filename, ErrMalformedModel, len(lm.LayerSizes), len(lm.Weights), len(lm.Biases))
+	}
+
+	nn := &NeuralNetwork{}
+	for i, w := range lm.Weights {
+		in, out := lm.LayerSizes[i], lm.LayerSizes[i+1]
+		if len(w) != out || len(lm.Biases[i]) != out {
+			return nil, fmt.Errorf("LoadLegacy: %s: %w: layer %d has %d rows and %d biases, want %d", filename, ErrShapeMismatch, i, len(w), len(lm.Biases[i]), out)
+		}
+		for _, row := range w {
+			if len(row) != in {
+				return nil, fmt.Errorf("LoadLegacy: %s: %w: layer %d has a row of %d weights, want %d", filename, ErrShapeMismatch, i, len(row), in)
+			}
+		}
+		nn.Layers = append(nn.Layers, &Layer{Weights: w, Biases: lm.Biases[i], Activation: act})
+	}
 	return nn, nil
 }
 
+// writeFile writes data to filename, gzip-compressing it for .gz names
+func writeFile(filename string, data []byte) error {
+	if !strings.HasSuffix(filename, ".gz") {
+		return os.WriteFile(filename, data, 0644)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readFile reads filename, decompressing it for .gz names
+func readFile(filename string) ([]byte, error) {
+	if !strings.HasSuffix(filename, ".gz") {
+		return os.ReadFile(filename)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+var (
+	activationFactories = map[string]func() ActivationFunc{}
+	activationNames     = map[reflect.Type]string{}
+)
+
+// RegisterActivation makes a custom activation serializable. Save names any
+// activation with the same concrete type as factory() by name, and Load calls
+// factory to rebuild it. Registered names take precedence over built-ins.
+func RegisterActivation(name string, factory func() ActivationFunc) {
+	name = strings.ToLower(name)
+	activationFactories[name] = factory
+	activationNames[reflect.TypeOf(factory())] = name
+}
+
 func activationName(act ActivationFunc) string {
+	if name, ok := activationNames[reflect.TypeOf(act)]; ok {
+		return name
+	}
 	switch act.(type) {
 	case Sigmoid:
 		return "sigmoid"
@@ -67,20 +208,35 @@ func activationName(act ActivationFunc) string {
 		return "relu"
 	case *Softmax:
 		return "softmax"
+	case Tanh:
+		return "tanh"
+	case Linear:
+		return "linear"
+	case Swish:
+		return "swish"
 	default:
 		return "unknown"
 	}
 }
 
-func activationFromName(name string) ActivationFunc {
+func lookupActivation(name string) (ActivationFunc, bool) {
+	if factory, ok := activationFactories[strings.ToLower(name)]; ok {
+		return factory(), true
+	}
 	switch strings.ToLower(name) {
 	case "sigmoid":
-		return Sigmoid{}
+		return Sigmoid{}, true
 	case "relu":
-		return ReLU{}
+		return ReLU{}, true
 	case "softmax":
-		return &Softmax{}
+		return &Softmax{}, true
+	case "tanh":
+		return Tanh{}, true
+	case "linear":
+		return Linear{}, true
+	case "swish":
+		return Swish{}, true
 	default:
-		panic("unknown activation: " + name)
+		return nil, false
 	}
 }
@@ -0,0 +1,248 @@
+package nnlib
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadOutputType(t *testing.T) {
+	for _, tc := range []struct {
+		output ActivationFunc
+		want   string
+	}{
+		{&Softmax{}, OutputProbs},
+		{Linear{}, OutputLogits},
+	} {
+		nn := NewNeuralNetwork([]int{2, 3}, []ActivationFunc{tc.output})
+		path := filepath.Join(t.TempDir(), "model.json")
+		if err := nn.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.OutputType != tc.want {
+			t.Errorf("%T output: loaded OutputType = %q, want %q", tc.output, loaded.OutputType, tc.want)
+		}
+
+		input := []float64{0.3, -0.8}
+		want, got := nn.Probabilities(input), loaded.Probabilities(input)
+		for i := range want {
+			if !approx(got[i], want[i], 1e-12) {
+				t.Errorf("%T output: Probabilities = %v, want %v", tc.output, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestClassifyRespectsOutputType(t *testing.T) {
+	nn := NewNeuralNetwork([]int{1, 2}, []ActivationFunc{Linear{}})
+	nn.Layers[0].Weights = [][]float64{{0}, {0}}
+	nn.Layers[0].Biases = []float64{0, 2}
+
+	// As logits, softmax(0, 2) gives class 1 probability 1/(1+e^-2).
+	class, p := nn.Classify([]float64{1})
+	if class != 1 || !approx(p, 0.8807970779778823, 1e-12) {
+		t.Errorf("logits: Classify = (%d, %v), want (1, 0.8808)", class, p)
+	}
+
+	nn.OutputType = OutputProbs
+	if class, p := nn.Classify([]float64{1}); class != 1 || p != 2 {
+		t.Errorf("probs: Classify = (%d, %v), want the raw output (1, 2)", class, p)
+	}
+}
+
+func TestSaveLoadGzip(t *testing.T) {
+	nn := NewNeuralNetwork([]int{3, 4, 2}, []ActivationFunc{Tanh{}, &Softmax{}})
+	path := filepath.Join(t.TempDir(), "model.json.gz")
+	if err := nn.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		t.Fatal("model.json.gz is not gzip-compressed")
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Layers[0].Weights, nn.Layers[0].Weights) ||
+		!reflect.DeepEqual(loaded.Layers[1].Biases, nn.Layers[1].Biases) {
+		t.Error("gzip round trip changed the parameters")
+	}
+	input := []float64{0.1, -0.2, 0.3}
+	if got, want := loaded.Predict(input), nn.Predict(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded Predict = %v, want %v", got, want)
+	}
+}
+
+// leakyReLU is a custom activation for the registry test.
+type leakyReLU struct{ slope float64 }
+
+func (l leakyReLU) Activate(x float64) float64 {
+	if x < 0 {
+		return l.slope * x
+	}
+	return x
+}
+
+func (l leakyReLU) Derivative(x float64) float64 {
+	if x < 0 {
+		return l.slope
+	}
+	return 1
+}
+
+func TestRegisterActivationRoundTrip(t *testing.T) {
+	RegisterActivation("LeakyReLU", func() ActivationFunc { return leakyReLU{slope: 0.1} })
+	nn := NewNeuralNetwork([]int{2, 3, 1}, []ActivationFunc{leakyReLU{slope: 0.1}, Linear{}})
+	nn.Layers[0].Weights = [][]float64{{1, -1}, {-2, 0.5}, {0.3, 0.3}}
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := nn.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"activation": "leakyrelu"`) {
+		t.Errorf("saved model does not name the custom activation:\n%s", data)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Layers[0].Activation.(leakyReLU); !ok {
+		t.Fatalf("loaded activation is %T, want leakyReLU", loaded.Layers[0].Activation)
+	}
+	input := []float64{0.2, 0.9}
+	if got, want := loaded.Predict(input)[0], nn.Predict(input)[0]; got != want {
+		t.Errorf("loaded Predict = %v, want %v", got, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"truncated.json":  `{"layers": [`,
+		"activation.json": `{"layers": [{"weights": [[1]], "biases": [0], "activation": "Nope"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); !errors.Is(err, ErrMalformedModel) {
+			t.Errorf("%s: error %v, want ErrMalformedModel", name, err)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: error %v, want fs.ErrNotExist", err)
+	}
+}
+
+// legacyPredict is the forward pass of oldfiles/nn.go: every layer,
+// including the output, applies the single activation.
+func legacyPredict(weights [][][]float64, biases [][]float64, act func(float64) float64, input []float64) []float64 {
+	a := input
+	for i, w := range weights {
+		z := make([]float64, len(w))
+		for j, row := range w {
+			z[j] = biases[i][j]
+			for k, x := range a {
+				z[j] += row[k] * x
+			}
+			z[j] = act(z[j])
+		}
+		a = z
+	}
+	return a
+}
+
+func TestLoadLegacy(t *testing.T) {
+	weights := [][][]float64{
+		{{0.5, -0.2}, {-0.7, 0.9}, {0.3, 0.3}},
+		{{1.1, -0.4, 0.6}, {-0.8, 0.2, 0.5}},
+	}
+	biases := [][]float64{{0.1, 0, -0.2}, {0.05, 0.3}}
+	relu := func(x float64) float64 { return math.Max(x, 0) }
+
+	dir := t.TempDir()
+	// Like the original LoadModel, any name other than "ReLU" means Tanh.
+	for name, act := range map[string]func(float64) float64{"ReLU": relu, "Tanh": math.Tanh, "Sigmoidish": math.Tanh} {
+		data, err := json.Marshal(map[string]any{
+			"layerSizes":     []int{2, 3, 2},
+			"weights":        weights,
+			"biases":         biases,
+			"learningRate":   0.1,
+			"activationFunc": name,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		nn, err := LoadLegacy(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, input := range [][]float64{{1, 2}, {-0.5, 0.3}, {2, -1}} {
+			got, want := nn.Predict(input), legacyPredict(weights, biases, act, input)
+			for k := range want {
+				if !approx(got[k], want[k], 1e-12) {
+					t.Errorf("%s: Predict(%v) = %v, want legacy %v", name, input, got, want)
+					break
+				}
+			}
+		}
+	}
+
+	path := filepath.Join(dir, "sizes.json")
+	content := `{"layerSizes": [1, 2], "weights": [[[1]]], "biases": [[0]], "activationFunc": "ReLU"}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLegacy(path); err == nil {
+		t.Error("LoadLegacy accepted layer sizes that disagree with the weights")
+	}
+}
+
+func TestLoadRejectsMismatchedTopology(t *testing.T) {
+	// Layer 0 outputs 2 values but layer 1 expects 3 inputs.
+	model := `{"layers": [
+		{"weights": [[1, 0], [0, 1]], "biases": [0, 0], "activation": "ReLU"},
+		{"weights": [[1, 1, 1]], "biases": [0], "activation": "Linear"}
+	]}`
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := os.WriteFile(path, []byte(model), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if !errors.Is(err, ErrShapeMismatch) {
+		t.Fatalf("Load error = %v, want ErrShapeMismatch", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "layer 1 takes 3 inputs but layer 0 outputs 2") {
+		t.Errorf("error %q does not describe the mismatch", msg)
+	}
+
+	nn := NewMLP(3, []int{2}, 1, ReLU{}, Linear{})
+	if err := nn.ValidateTopology(); err != nil {
+		t.Errorf("NewMLP network: %v", err)
+	}
+	nn.Layers[0].Biases = nn.Layers[0].Biases[:1]
+	if err := nn.ValidateTopology(); !errors.Is(err, ErrShapeMismatch) {
+		t.Errorf("missing bias: error %v, want ErrShapeMismatch", err)
+	}
+	nn.Layers[0].Biases = append(nn.Layers[0].Biases, 0)
+	nn.Layers[1].Weights[0] = nn.Layers[1].Weights[0][:1]
+	if err := nn.ValidateTopology(); !errors.Is(err, ErrShapeMismatch) {
+		t.Errorf("ragged row: error %v, want ErrShapeMismatch", err)
+	}
+}
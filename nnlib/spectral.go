@@ -0,0 +1,87 @@
+package nnlib
+
+import "math"
+
+const (
+	spectralIterations = 500
+	spectralTolerance  = 1e-10
+)
+
+// SpectralNorms returns the largest singular value of each layer's weight
+// matrix, estimated by power iteration on WᵀW. Their product bounds the
+// network's Lipschitz constant for 1-Lipschitz activations.
+func (nn *NeuralNetwork) SpectralNorms() []float64 {
+	norms := make([]float64, len(nn.Layers))
+	for i, layer := range nn.Layers {
+		norms[i] = spectralNorm(layer.Weights)
+	}
+	return norms
+}
+
+// spectralNorm runs power iteration to convergence from a fixed start vector
+func spectralNorm(w [][]float64) float64 {
+	_, sigma := powerIterate(w, spectralStart(len(w)), spectralIterations)
+	return sigma
+}
+
+// spectralStart is a deterministic, non-symmetric start vector, unlikely to be
+// orthogonal to the top singular vector
+func spectralStart(n int) []float64 {
+	u := make([]float64, n)
+	for i := range u {
+		u[i] = 1 + float64(i)/float64(n)
+	}
+	return u
+}
+
+// powerIterate runs up to maxSteps steps of powerStep from u, stopping early
+// once the estimate settles
+func powerIterate(w [][]float64, u []float64, maxSteps int) ([]float64, float64) {
+	sigma := 0.0
+	for step := 0; step < maxSteps; step++ {
+		var next float64
+		u, next = powerStep(w, u)
+		if next == 0 || math.Abs(next-sigma) <= spectralTolerance*next {
+			return u, next
+		}
+		sigma = next
+	}
+	return u, sigma
+}
+
+// powerStep performs one power iteration step on the left singular vector
+// estimate u: v = Wᵀu/‖Wᵀu‖, σ = ‖Wv‖, u' = Wv/σ
+func powerStep(w [][]float64, u []float64) ([]float64, float64) {
+	if len(w) == 0 || len(w[0]) == 0 {
+		return u, 0
+	}
+	v := make([]float64, len(w[0]))
+	for i, row := range w {
+		for j, x := range row {
+			v[j] += x * u[i]
+		}
+	}
+	n := vecNorm(v)
+	if n == 0 {
+		return u, 0
+	}
+	v = ScalarMultiply(v, 1/n)
+
+	wv := make([]float64, len(w))
+	for i, row := range w {
+		wv[i], _ = Dot(row, v)
+	}
+	sigma := vecNorm(wv)
+	if sigma == 0 {
+		return u, 0
+	}
+	return ScalarMultiply(wv, 1/sigma), sigma
+}
+
+func vecNorm(v []float64) float64 {
+	sum := 0.0
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
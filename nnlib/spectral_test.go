@@ -0,0 +1,74 @@
+package nnlib
+
+import "testing"
+
+func TestSpectralNorms(t *testing.T) {
+	nn := NewNeuralNetwork([]int{3, 3, 2}, []ActivationFunc{Tanh{}, Linear{}})
+	nn.Layers[0].Weights = [][]float64{{2, 0, 0}, {0, -5, 0}, {0, 0, 1}}
+	// Rank one: [[1 2 2] [2 4 4]] = [1 2]ᵀ[1 2 2], so σ = √5·3.
+	nn.Layers[1].Weights = [][]float64{{1, 2, 2}, {2, 4, 4}}
+
+	norms := nn.SpectralNorms()
+	if !approx(norms[0], 5, 1e-8) {
+		t.Errorf("diagonal layer norm = %v, want the largest |eigenvalue| 5", norms[0])
+	}
+	if want := 3 * 2.23606797749979; !approx(norms[1], want, 1e-8) {
+		t.Errorf("rank-one layer norm = %v, want %v", norms[1], want)
+	}
+}
+
+// spectralNet is a 6-4-3 network whose first layer is spectrally normalized
+// and whose weights are far from unit norm.
+func spectralNet() *NeuralNetwork {
+	SetSeed(7)
+	nn := &NeuralNetwork{Layers: []*Layer{
+		NewSpectralNormLayer(6, 4, Tanh{}),
+		NewLayer(4, 3, &Softmax{}),
+	}}
+	for _, row := range nn.Layers[0].Weights {
+		for j := range row {
+			row[j] *= 40
+		}
+	}
+	return nn
+}
+
+func TestSpectralNormLayerHasUnitNorm(t *testing.T) {
+	nn := spectralNet()
+	if norm := nn.SpectralNorms()[0]; approx(norm, 1, 0.1) {
+		t.Fatalf("raw weights already have norm %v; the test needs them scaled", norm)
+	}
+	for i := 0; i < 20; i++ {
+		nn.Predict([]float64{1, 0, -1, 0.5, 0.2, 0})
+	}
+	if norm := spectralNorm(nn.Layers[0].EffectiveWeights()); !approx(norm, 1, 1e-6) {
+		t.Errorf("effective weight spectral norm = %v, want 1", norm)
+	}
+}
+
+func TestPredictSparseSpectralNorm(t *testing.T) {
+	nn := spectralNet()
+	indices, values := []int{1, 4}, []float64{2, -0.5}
+	dense := []float64{0, 2, 0, 0, -0.5, 0}
+
+	want := nn.Predict(dense)
+	got := nn.PredictSparse(indices, values)
+	for i := range want {
+		if !approx(got[i], want[i], 1e-12) {
+			t.Fatalf("PredictSparse = %v, want dense Predict %v", got, want)
+		}
+	}
+}
+
+func TestQuantizedPredictSpectralNorm(t *testing.T) {
+	nn := spectralNet()
+	input := []float64{1, 0, -1, 0.5, 0.2, 0}
+	want := nn.Predict(input)
+	nn.Quantize()
+	got := nn.QuantizedPredict(input)
+	for i := range want {
+		if !approx(got[i], want[i], 1e-2) {
+			t.Fatalf("QuantizedPredict = %v, want float Predict %v", got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package nnlib
 
 import (
 	"errors"
+	"fmt"
 	"math"
 )
 
@@ -139,3 +140,140 @@ func Clip(vec []float64, minVal, maxVal float64) []float64 {
 	}
 	return clipped
 }
+
+// RSquared computes the coefficient of determination of predictions against
+// targets, pooling all output dimensions around their per-dimension means.
+// Returns 0 if inputs are empty, lengths don't match, or targets are constant.
+func RSquared(predictions, targets [][]float64) float64 {
+	if len(predictions) == 0 || len(predictions) != len(targets) {
+		return 0
+	}
+	dims := len(targets[0])
+	means := make([]float64, dims)
+	for _, t := range targets {
+		for j := range means {
+			means[j] += t[j]
+		}
+	}
+	for j := range means {
+		means[j] /= float64(len(targets))
+	}
+
+	ssRes, ssTot := 0.0, 0.0
+	for i := range targets {
+		for j := range means {
+			diff := targets[i][j] - predictions[i][j]
+			ssRes += diff * diff
+			dev := targets[i][j] - means[j]
+			ssTot += dev * dev
+		}
+	}
+	if ssTot == 0 {
+		return 0
+	}
+	return 1 - ssRes/ssTot
+}
+
+// LogSumExp computes log(Σ exp(v)) stably by factoring out the maximum.
+// Returns -Inf for empty slices.
+func LogSumExp(vec []float64) float64 {
+	if len(vec) == 0 {
+		return math.Inf(-1)
+	}
+	maxVal := vec[0]
+	for _, v := range vec {
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+	if math.IsInf(maxVal, 0) {
+		return maxVal
+	}
+	sum := 0.0
+	for _, v := range vec {
+		sum += math.Exp(v - maxVal)
+	}
+	return maxVal + math.Log(sum)
+}
+
+// ClassDistribution counts the targets of each class, taking the class of a
+// one-hot target as its ArgMax. Empty targets are skipped.
+func ClassDistribution(targets [][]float64) map[int]int {
+	counts := make(map[int]int)
+	for _, t := range targets {
+		if c := ArgMax(t); c >= 0 {
+			counts[c]++
+		}
+	}
+	return counts
+}
+
+// GiniImpurity computes 1 - Σ p_c² for the class proportions in counts, 0
+// for a single class and 1 - 1/k for k balanced classes.
+// Returns 0 for empty counts.
+func GiniImpurity(counts map[int]int) float64 {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	gini := 1.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		gini -= p * p
+	}
+	return gini
+}
+
+// RunningStats accumulates the per-feature mean and variance of a stream of
+// vectors with Welford's algorithm, so normalization statistics can be kept
+// without storing the data. The zero value is ready to use; the first Update
+// fixes the vector length.
+type RunningStats struct {
+	n    int
+	mean []float64
+	m2   []float64
+}
+
+// Update adds x to the statistics. It panics if x's length differs from
+// earlier updates.
+func (r *RunningStats) Update(x []float64) {
+	if r.mean == nil {
+		r.mean = make([]float64, len(x))
+		r.m2 = make([]float64, len(x))
+	}
+	if len(x) != len(r.mean) {
+		panic(fmt.Sprintf("RunningStats: got %d values, want %d", len(x), len(r.mean)))
+	}
+	r.n++
+	for j, v := range x {
+		d := v - r.mean[j]
+		r.mean[j] += d / float64(r.n)
+		r.m2[j] += d * (v - r.mean[j])
+	}
+}
+
+// Count returns the number of vectors seen.
+func (r *RunningStats) Count() int {
+	return r.n
+}
+
+// Mean returns a copy of the per-feature mean (nil before any Update).
+func (r *RunningStats) Mean() []float64 {
+	return append([]float64(nil), r.mean...)
+}
+
+// Variance returns the per-feature population variance (1/N) Σ (x - mean)²
+// (nil before any Update).
+func (r *RunningStats) Variance() []float64 {
+	if r.n == 0 {
+		return nil
+	}
+	variance := make([]float64, len(r.m2))
+	for j, m2 := range r.m2 {
+		variance[j] = m2 / float64(r.n)
+	}
+	return variance
+}
@@ -0,0 +1,117 @@
+package nnlib
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLogSumExp(t *testing.T) {
+	vec := []float64{0.5, -1.2, 2.0, 0}
+	naive := 0.0
+	for _, v := range vec {
+		naive += math.Exp(v)
+	}
+	if got := LogSumExp(vec); !approx(got, math.Log(naive), 1e-12) {
+		t.Errorf("LogSumExp = %v, want %v", got, math.Log(naive))
+	}
+
+	// exp(1000) overflows, but the result is 1000 + log 2.
+	if got := LogSumExp([]float64{1000, 1000}); !approx(got, 1000+math.Ln2, 1e-9) {
+		t.Errorf("LogSumExp(1000, 1000) = %v, want %v", got, 1000+math.Ln2)
+	}
+	if got := LogSumExp([]float64{-1000, -1000}); !approx(got, -1000+math.Ln2, 1e-9) {
+		t.Errorf("LogSumExp(-1000, -1000) = %v, want %v", got, -1000+math.Ln2)
+	}
+
+	probs := (&Softmax{}).ActivateVector([]float64{1000, 999, 0})
+	if math.IsNaN(probs[0]) || !approx(probs[0], 1/(1+math.Exp(-1)), 1e-12) || probs[2] != 0 {
+		t.Errorf("softmax of large logits = %v", probs)
+	}
+}
+
+func TestRunningStatsMatchesBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	// A large offset on feature 1 is where naive sum-of-squares loses digits.
+	var data [][]float64
+	for i := 0; i < 500; i++ {
+		data = append(data, []float64{rng.NormFloat64(), 1e9 + 0.5*rng.NormFloat64(), 3})
+	}
+
+	var stats RunningStats
+	if stats.Mean() != nil || stats.Variance() != nil {
+		t.Error("empty RunningStats reports statistics")
+	}
+	for _, x := range data {
+		stats.Update(x)
+	}
+	if stats.Count() != len(data) {
+		t.Errorf("Count = %d, want %d", stats.Count(), len(data))
+	}
+
+	mean, variance := stats.Mean(), stats.Variance()
+	for j := range data[0] {
+		m := 0.0
+		for _, x := range data {
+			m += x[j]
+		}
+		m /= float64(len(data))
+		v := 0.0
+		for _, x := range data {
+			v += (x[j] - m) * (x[j] - m)
+		}
+		v /= float64(len(data))
+
+		if !approx(mean[j], m, 1e-9*math.Max(1, math.Abs(m))) {
+			t.Errorf("feature %d: mean = %v, want %v", j, mean[j], m)
+		}
+		if !approx(variance[j], v, 1e-6*math.Max(v, 1e-12)) {
+			t.Errorf("feature %d: variance = %v, want %v", j, variance[j], v)
+		}
+	}
+	if variance[2] != 0 {
+		t.Errorf("constant feature variance = %v, want 0", variance[2])
+	}
+
+	mean[0] = 1e6
+	if stats.Mean()[0] == 1e6 {
+		t.Error("Mean returned the internal slice")
+	}
+	expectPanic(t, func() { stats.Update([]float64{1, 2}) })
+}
+
+func TestClassDistributionAndGini(t *testing.T) {
+	// 6 of class 0, 3 of class 2, 1 of class 1, and an empty target.
+	var targets [][]float64
+	for c, n := range []int{6, 1, 3} {
+		for i := 0; i < n; i++ {
+			target := make([]float64, 3)
+			target[c] = 1
+			targets = append(targets, target)
+		}
+	}
+	targets = append(targets, nil)
+
+	counts := ClassDistribution(targets)
+	if want := map[int]int{0: 6, 1: 1, 2: 3}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("ClassDistribution = %v, want %v", counts, want)
+	}
+	// 1 - (0.36 + 0.01 + 0.09)
+	if got := GiniImpurity(counts); !approx(got, 0.54, 1e-12) {
+		t.Errorf("GiniImpurity = %v, want 0.54", got)
+	}
+
+	for _, c := range []struct {
+		counts map[int]int
+		want   float64
+	}{
+		{map[int]int{}, 0},
+		{map[int]int{4: 9}, 0},
+		{map[int]int{0: 5, 1: 5, 2: 5, 3: 5}, 0.75},
+	} {
+		if got := GiniImpurity(c.counts); !approx(got, c.want, 1e-12) {
+			t.Errorf("GiniImpurity(%v) = %v, want %v", c.counts, got, c.want)
+		}
+	}
+}
@@ -191,6 +191,10 @@ func (m *MDP) LoadFromCSV(path string) error {
 
 		m.States = appendIfMissing(m.States, s)
 		m.States = appendIfMissing(m.States, ns)
+
+		if m.Transitions[s] == nil {
+			m.Transitions[s] = make(map[Action][]Transition)
+		}
 		m.Actions[s] = appendIfMissingAction(m.Actions[s], a)
 		m.Transitions[s][a] = append(m.Transitions[s][a], Transition{NextState: ns, Prob: p, Reward: r})
 	}
@@ -216,6 +220,10 @@ func (m *MDP) LoadFromJSON(path string) error {
 
 		m.States = appendIfMissing(m.States, s)
 		m.States = appendIfMissing(m.States, ns)
+
+		if m.Transitions[s] == nil {
+			m.Transitions[s] = make(map[Action][]Transition)
+		}
 		m.Actions[s] = appendIfMissingAction(m.Actions[s], a)
 		m.Transitions[s][a] = append(m.Transitions[s][a], Transition{NextState: ns, Prob: entry.Prob, Reward: entry.Reward})
 	}
@@ -0,0 +1,42 @@
+package mdplib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFromCSVInitializesInnerMaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdp.csv")
+	data := "state,action,next,prob,reward\nA,left,B,0.5,1\nA,left,A,0.5,0\nB,stay,B,1,2\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MDP{Actions: make(map[State][]Action), Transitions: make(map[State]map[Action][]Transition)}
+	if err := m.LoadFromCSV(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(m.Transitions["A"]["left"]); got != 2 {
+		t.Errorf("A/left has %d transitions, want 2", got)
+	}
+	if got := m.Transitions["B"]["stay"]; len(got) != 1 || got[0].Reward != 2 {
+		t.Errorf("B/stay = %v, want one transition with reward 2", got)
+	}
+}
+
+func TestLoadFromJSONInitializesInnerMaps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdp.json")
+	data := `[{"state":"A","action":"go","next_state":"B","prob":1,"reward":3}]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MDP{Actions: make(map[State][]Action), Transitions: make(map[State]map[Action][]Transition)}
+	if err := m.LoadFromJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Transitions["A"]["go"]; len(got) != 1 || got[0].NextState != "B" {
+		t.Errorf("A/go = %v, want one transition to B", got)
+	}
+}